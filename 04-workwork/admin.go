@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ensureAdmins 把配置中的管理员用户名提升为管理员角色（启动时调用）
+func (s *Server) ensureAdmins() error {
+	if len(s.cfg.AdminUsers) == 0 {
+		return nil
+	}
+	return s.db.Model(&User{}).
+		Where("username IN ? AND role <> ?", s.cfg.AdminUsers, RoleAdmin).
+		Update("role", RoleAdmin).Error
+}
+
+// AdminListUsers 管理员获取用户列表
+func (s *Server) AdminListUsers(c *gin.Context) {
+	var users []User
+
+	query := s.db.Model(&User{})
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	// 分页参数
+	params, err := parseListParams(c, adminListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var total int64
+	query.Count(&total)
+
+	if err := query.Offset(params.Offset()).Limit(params.Limit).Order("id asc").Find(&users).Error; err != nil {
+		c.Error(errInternal("Failed to fetch users", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Users retrieved successfully",
+		Data: gin.H{
+			"users": newUserAccountViews(users),
+			"pagination": gin.H{
+				"page":  params.Page,
+				"limit": params.Limit,
+				"total": total,
+			},
+		},
+	})
+}
+
+// AdminUpdateUserRole 管理员修改用户角色
+func (s *Server) AdminUpdateUserRole(c *gin.Context) {
+	id := c.Param("id")
+	targetID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	// 防止管理员把自己降级后无人可以管理
+	if uint(targetID) == getCurrentUserID(c) {
+		c.Error(errOwnRole)
+		return
+	}
+
+	var user User
+	if err := s.db.First(&user, targetID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+
+	if err := s.db.Model(&user).Update("role", req.Role).Error; err != nil {
+		c.Error(errInternal("Failed to update user role", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "User role updated successfully",
+		Data:    newUserAccountView(user),
+	})
+}
+
+// AdminDeletePost 管理员下架文章（不受作者限制）
+func (s *Server) AdminDeletePost(c *gin.Context) {
+	id := c.Param("id")
+	postID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidPostID)
+		return
+	}
+
+	var post Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errPostNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch post", err))
+		}
+		return
+	}
+
+	// 与作者删除一样移入回收站并级联评论，但标记为下架，作者不能自行恢复
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := bumpPostVersion(tx, &post, nil); err != nil {
+			return err
+		}
+		return trashPost(tx, &post, true)
+	})
+	if errors.Is(err, errStalePost) {
+		c.Error(s.postModified(c, post.ID))
+		return
+	}
+	if err != nil {
+		c.Error(errInternal("Failed to delete post", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Post taken down successfully",
+	})
+}
+
+// AdminListComments 评论审核队列，默认列出待审核评论
+func (s *Server) AdminListComments(c *gin.Context) {
+	var comments []Comment
+
+	status := c.DefaultQuery("status", CommentStatusPending)
+	switch status {
+	case CommentStatusApproved, CommentStatusPending, CommentStatusRejected:
+	default:
+		c.Error(errValidation(FieldError{Field: "status", Code: "oneof", Param: "approved pending rejected"}))
+		return
+	}
+	query := s.db.Model(&Comment{}).Where("status = ?", status)
+	if postID := c.Query("post_id"); postID != "" {
+		query = query.Where("post_id = ?", postID)
+	}
+
+	// 分页参数
+	params, err := parseListParams(c, adminListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var total int64
+	query.Count(&total)
+
+	// 审核队列按提交时间先后处理
+	if err := query.Preload("User").Offset(params.Offset()).Limit(params.Limit).Order("created_at asc").Find(&comments).Error; err != nil {
+		c.Error(errInternal("Failed to fetch comments", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Comments retrieved successfully",
+		Data: gin.H{
+			"comments": newCommentViews(comments),
+			"pagination": gin.H{
+				"page":  params.Page,
+				"limit": params.Limit,
+				"total": total,
+			},
+		},
+	})
+}
+
+// AdminUpdateCommentStatus 审核评论（通过/拒绝/退回待审）
+func (s *Server) AdminUpdateCommentStatus(c *gin.Context) {
+	id := c.Param("id")
+	commentID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidCommentID)
+		return
+	}
+
+	var req UpdateCommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	var comment Comment
+	if err := s.db.Preload("User").First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errCommentNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch comment", err))
+		}
+		return
+	}
+
+	if err := s.db.Model(&comment).Update("status", req.Status).Error; err != nil {
+		c.Error(errInternal("Failed to update comment status", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Comment status updated successfully",
+		Data:    newCommentView(comment),
+	})
+}
+
+// AdminDeleteComment 管理员/版主删除评论及其所有回复
+func (s *Server) AdminDeleteComment(c *gin.Context) {
+	id := c.Param("id")
+	commentID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidCommentID)
+		return
+	}
+
+	var comment Comment
+	if err := s.db.First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errCommentNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch comment", err))
+		}
+		return
+	}
+
+	if err := s.transaction(func(tx *gorm.DB) error {
+		return deleteCommentThread(tx, comment.ID)
+	}); err != nil {
+		c.Error(errInternal("Failed to delete comment", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Comment deleted successfully",
+	})
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,214 +14,290 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-const (
-	JWTSecret = "your_secret_key_change_in_production"
-)
-
 // Register 用户注册
-func Register(c *gin.Context) {
+func (s *Server) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid request data: " + err.Error(),
-		})
+		c.Error(bindError(err))
+		return
+	}
+
+	// 检查密码强度
+	if fields := s.passwords.Check("password", req.Password); len(fields) > 0 {
+		c.Error(errValidation(fields...))
 		return
 	}
 
-	// 检查用户名是否已存在
+	// 检查用户名是否已存在（唯一约束也包括已注销的账号）
 	var existingUser User
-	if err := db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, APIResponse{
-			Success: false,
-			Error:   "Username already exists",
-		})
+	if err := s.db.Unscoped().Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
+		c.Error(errUsernameTaken)
 		return
 	}
 
 	// 检查邮箱是否已存在
-	if err := db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, APIResponse{
-			Success: false,
-			Error:   "Email already exists",
-		})
+	if err := s.db.Unscoped().Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		c.Error(errEmailTaken)
 		return
 	}
 
 	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwords.Hash(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to hash password",
-		})
+		c.Error(errInternal("Failed to hash password", err))
 		return
 	}
 
-	// 创建用户
+	// 创建用户：注册的账号一律是普通用户，配置中的管理员只在启动时对已存在的账号授予
+	// （否则任何人都可以抢注配置中的管理员用户名，包括已注销管理员释放的用户名）
 	user := User{
 		Username: req.Username,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		Email:    req.Email,
+		Role:     RoleUser,
 	}
 
-	if err := db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to create user",
-		})
+	if err := s.db.Create(&user).Error; err != nil {
+		c.Error(errInternal("Failed to create user", err))
 		return
 	}
 
+	// 验证邮件发送失败不影响注册，用户可以稍后重新发送
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("send verification email to user %d: %v", user.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, APIResponse{
 		Success: true,
 		Message: "User registered successfully",
-		Data: gin.H{
-			"user_id":  user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
+		Data:    newUserAccountView(user),
 	})
 }
 
 // Login 用户登录
-func Login(c *gin.Context) {
+func (s *Server) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid request data: " + err.Error(),
-		})
+		c.Error(bindError(err))
 		return
 	}
 
-	// 查找用户
-	var user User
-	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, APIResponse{
-			Success: false,
-			Error:   "Invalid username or password",
-		})
+	// 失败次数过多时先等待退避时间或锁定结束，不再校验密码
+	userKey, ipKey := loginUserKey(req.Username), loginIPKey(c.ClientIP())
+	if wait, locked := s.logins.Check(userKey, ipKey); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		if locked {
+			c.Error(errAccountLocked)
+		} else {
+			c.Error(errLoginThrottled)
+		}
 		return
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, APIResponse{
-			Success: false,
-			Error:   "Invalid username or password",
-		})
+	// 查找用户并验证密码
+	var user User
+	err := s.db.Where("username = ?", req.Username).First(&user).Error
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	}
+	if err != nil {
+		s.loginFailed(c, req.Username, user.ID)
+		c.Error(errBadCredentials)
 		return
 	}
+	s.logins.Reset(userKey)
 
-	// 生成JWT token
-	token, err := generateJWT(user.ID, user.Username)
+	// 生成访问令牌和刷新令牌
+	data, err := s.issueTokens(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to generate token",
-		})
+		c.Error(errInternal("Failed to generate token", err))
 		return
 	}
+	data["user"] = newUserAccountView(user)
 
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
 		Message: "Login successful",
-		Data: gin.H{
-			"token":    token,
-			"user_id":  user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
+		Data:    data,
 	})
 }
 
-// generateJWT 生成JWT token
-func generateJWT(userID uint, username string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id":  userID,
-		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // 24小时过期
-		"iat":      time.Now().Unix(),
+// loginFailed 记录一次登录失败，触发锁定时写入审计记录
+// userID 为0表示用户名不存在
+func (s *Server) loginFailed(c *gin.Context, username string, userID uint) {
+	var uid *uint
+	if userID != 0 {
+		uid = &userID
+	}
+	lockout := time.Duration(s.cfg.Auth.Login.LockoutDuration)
+
+	if s.logins.Fail(loginUserKey(username), s.cfg.Auth.Login.LockoutThreshold) {
+		s.audit(c, AuditLog{
+			Action:   AuditLoginLocked,
+			UserID:   uid,
+			Username: username,
+			Detail:   fmt.Sprintf("%d failed login attempts, locked for %s", s.cfg.Auth.Login.LockoutThreshold, lockout),
+		})
+	}
+	if s.logins.Fail(loginIPKey(c.ClientIP()), s.cfg.Auth.Login.IPLockoutThreshold) {
+		s.audit(c, AuditLog{
+			Action:   AuditLoginIPLocked,
+			Username: username,
+			Detail:   fmt.Sprintf("%d failed login attempts from this IP, locked for %s", s.cfg.Auth.Login.IPLockoutThreshold, lockout),
+		})
+	}
+}
+
+// generateJWT 生成JWT访问令牌，有效期由配置 jwt.token_ttl 决定
+// 每个令牌带有唯一的jti，注销时按jti吊销
+func (s *Server) generateJWT(user User) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := JWTClaims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    s.cfg.JWT.Issuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Audience:  jwt.ClaimStrings{s.cfg.JWT.Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(s.cfg.JWT.TokenTTL))),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JWTSecret))
+	return s.keys.Sign(claims)
+}
+
+// parseJWT 校验签名（按kid选择密钥）、iss、aud、exp、nbf 和 iat
+func (s *Server) parseJWT(tokenString string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keys.Keyfunc,
+		jwt.WithValidMethods(s.keys.Methods()),
+		jwt.WithIssuer(s.cfg.JWT.Issuer),
+		jwt.WithAudience(s.cfg.JWT.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
 }
 
 // AuthMiddleware JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+func (s *Server) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Error:   "Authorization header is required",
-			})
+			c.Error(errMissingToken)
 			c.Abort()
 			return
 		}
 
-		// 移除"Bearer "前缀
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
-		}
-
-		// 解析JWT token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(JWTSecret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Error:   "Invalid or expired token",
-			})
+		if err := s.authenticate(c, tokenString); err != nil {
+			c.Error(err)
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		// 提取用户信息
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Error:   "Invalid token claims",
-			})
-			c.Abort()
-			return
+// OptionalAuthMiddleware 可选认证：没有 Authorization 请求头时按匿名用户继续处理，
+// 提供了令牌则必须有效（与 AuthMiddleware 相同的校验）
+func (s *Server) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString := c.GetHeader("Authorization"); tokenString != "" {
+			if err := s.authenticate(c, tokenString); err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
 		}
+		c.Next()
+	}
+}
 
-		userID, ok := claims["user_id"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Error:   "Invalid user ID in token",
-			})
-			c.Abort()
-			return
-		}
+// authenticate 校验 Authorization 请求头中的令牌，并把用户信息存储到上下文中
+func (s *Server) authenticate(c *gin.Context, tokenString string) error {
+	// 移除"Bearer "前缀
+	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+		tokenString = tokenString[7:]
+	}
+
+	// 解析JWT token
+	claims, err := s.parseJWT(tokenString)
+	if err != nil {
+		return errBadToken
+	}
+
+	if claims.UserID == 0 || claims.Username == "" || claims.ID == "" {
+		return errBadTokenClaims
+	}
+
+	// 检查令牌是否已被吊销（注销）
+	revoked, err := s.isTokenRevoked(claims.ID)
+	if err != nil {
+		return errInternal("Failed to verify token", err)
+	}
+	if revoked {
+		return errTokenRevoked
+	}
+
+	// 账号注销后，之前签发的令牌全部失效
+	active, err := s.isUserActive(claims.UserID)
+	if err != nil {
+		return errInternal("Failed to verify token", err)
+	}
+	if !active {
+		return errTokenRevoked
+	}
 
-		username, ok := claims["username"].(string)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Error:   "Invalid username in token",
-			})
+	// 将用户信息存储到上下文中
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("jti", claims.ID)
+	c.Set("token_exp", claims.ExpiresAt.Time.UTC())
+	return nil
+}
+
+// RequireRole 角色校验中间件，必须挂在 AuthMiddleware 之后
+// 管理员默认拥有全部角色的权限
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasRole(getCurrentRole(c), roles...) {
+			c.Error(errNoPermission)
 			c.Abort()
 			return
 		}
-
-		// 将用户信息存储到上下文中
-		c.Set("user_id", uint(userID))
-		c.Set("username", username)
 		c.Next()
 	}
 }
 
+// hasRole 判断角色是否满足要求
+func hasRole(role string, allowed ...string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, r := range allowed {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
 // getCurrentUserID 从上下文中获取当前用户ID
 func getCurrentUserID(c *gin.Context) uint {
 	userID, exists := c.Get("user_id")
@@ -235,3 +315,30 @@ func getCurrentUsername(c *gin.Context) string {
 	}
 	return username.(string)
 }
+
+// getCurrentRole 从上下文中获取当前用户角色
+func getCurrentRole(c *gin.Context) string {
+	role, exists := c.Get("role")
+	if !exists {
+		return ""
+	}
+	return role.(string)
+}
+
+// getCurrentTokenID 从上下文中获取当前访问令牌的jti
+func getCurrentTokenID(c *gin.Context) string {
+	jti, exists := c.Get("jti")
+	if !exists {
+		return ""
+	}
+	return jti.(string)
+}
+
+// getCurrentTokenExpiry 从上下文中获取当前访问令牌的过期时间
+func getCurrentTokenExpiry(c *gin.Context) time.Time {
+	exp, exists := c.Get("token_exp")
+	if !exists {
+		return time.Time{}
+	}
+	return exp.(time.Time)
+}
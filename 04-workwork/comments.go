@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -9,119 +10,404 @@ import (
 )
 
 // GetComments 获取指定文章的所有评论
-func GetComments(c *gin.Context) {
+func (s *Server) GetComments(c *gin.Context) {
 	postIDStr := c.Param("postId")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid post ID",
-		})
+		c.Error(errInvalidPostID)
 		return
 	}
 	
 	// 检查文章是否存在
 	var post Post
-	if err := db.First(&post, postID).Error; err != nil {
+	if err := s.db.First(&post, postID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, APIResponse{
-				Success: false,
-				Error:   "Post not found",
-			})
+			c.Error(errPostNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Error:   "Failed to fetch post",
-			})
+			c.Error(errInternal("Failed to fetch post", err))
 		}
 		return
 	}
+	if !post.visibleTo(getCurrentUserID(c)) {
+		c.Error(errPostNotFound)
+		return
+	}
 	
-	// 获取评论列表
+	// 树形模式：按回复关系返回嵌套结构
+	if c.Query("mode") == "tree" {
+		s.getCommentTree(c, post.ID)
+		return
+	}
+
+	params, err := parseListParams(c, commentListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// 获取评论列表（只返回审核通过的评论）
 	var comments []Comment
-	query := db.Where("post_id = ?", postID).Preload("User")
-	
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	offset := (page - 1) * limit
-	
-	if err := query.Offset(offset).Limit(limit).Order("created_at desc").Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to fetch comments",
+	query := params.applyFilters(s.db.Model(&Comment{}).Where("post_id = ? AND status = ?", postID, CommentStatusApproved))
+	if params.wants("author") {
+		query = query.Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "username", "nickname", "avatar")
 		})
+	}
+
+	if err := params.applyPage(query).Find(&comments).Error; err != nil {
+		c.Error(errInternal("Failed to fetch comments", err))
 		return
 	}
-	
+
 	// 获取评论总数
 	var total int64
-	db.Model(&Comment{}).Where("post_id = ?", postID).Count(&total)
-	
+	params.applyFilters(s.db.Model(&Comment{}).Where("post_id = ? AND status = ?", postID, CommentStatusApproved)).Count(&total)
+
+	comments, pagination := finishPage(params, comments, total)
+	items, err := params.project(newCommentViews(comments))
+	if err != nil {
+		c.Error(errInternal("Failed to fetch comments", err))
+		return
+	}
+
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
 		Message: "Comments retrieved successfully",
 		Data: gin.H{
-			"comments": comments,
-			"pagination": gin.H{
-				"page":  page,
-				"limit": limit,
-				"total": total,
-			},
+			"comments":   items,
+			"pagination": pagination,
 		},
 	})
 }
 
 // CreateComment 创建新评论
-func CreateComment(c *gin.Context) {
+func (s *Server) CreateComment(c *gin.Context) {
 	var req CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid request data: " + err.Error(),
-		})
+		c.Error(bindError(err))
 		return
 	}
 	
 	// 检查文章是否存在
 	var post Post
-	if err := db.First(&post, req.PostID).Error; err != nil {
+	if err := s.db.First(&post, req.PostID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, APIResponse{
-				Success: false,
-				Error:   "Post not found",
-			})
+			c.Error(errPostNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Error:   "Failed to fetch post",
-			})
+			c.Error(errInternal("Failed to fetch post", err))
 		}
 		return
 	}
+	// 只能评论已发布的文章；未发布的文章对作者以外的人视为不存在
+	if !post.visibleTo(getCurrentUserID(c)) {
+		c.Error(errPostNotFound)
+		return
+	}
+	if post.Status != PostStatusPublished {
+		c.Error(errCommentsClosed)
+		return
+	}
 	
+	// 回复评论时检查父评论是否存在且属于同一篇文章
+	if req.ParentID != nil {
+		var parent Comment
+		if err := s.db.First(&parent, *req.ParentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.Error(errParentNotFound)
+			} else {
+				c.Error(errInternal("Failed to fetch parent comment", err))
+			}
+			return
+		}
+		if parent.PostID != req.PostID {
+			c.Error(errParentOtherPost)
+			return
+		}
+		if parent.Status != CommentStatusApproved {
+			c.Error(errParentNotApproved)
+			return
+		}
+	}
+
 	userID := getCurrentUserID(c)
 	
 	comment := Comment{
-		Content: req.Content,
-		UserID:  userID,
-		PostID:  req.PostID,
+		Content:     req.Content,
+		ContentHTML: renderMarkdown(req.Content),
+		Status:      s.initialCommentStatus(),
+		UserID:      userID,
+		PostID:      req.PostID,
+		ParentID:    req.ParentID,
 	}
 	
-	if err := db.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to create comment",
-		})
+	if err := s.db.Create(&comment).Error; err != nil {
+		c.Error(errInternal("Failed to create comment", err))
 		return
 	}
 	
 	// 重新查询以获取用户信息
-	db.Preload("User").First(&comment, comment.ID)
+	s.db.Preload("User").First(&comment, comment.ID)
 	
+	message := "Comment created successfully"
+	if comment.Status == CommentStatusPending {
+		message = "Comment submitted for review"
+	}
+
 	c.JSON(http.StatusCreated, APIResponse{
 		Success: true,
-		Message: "Comment created successfully",
-		Data:    comment,
+		Message: message,
+		Data:    newCommentView(comment),
+	})
+}
+
+// getCommentTree 返回树形评论
+// page/limit 对顶层评论（或 parent_id 指定评论的直接回复）分页，
+// depth 控制向下展开的层数，replies_limit 控制每个节点最多返回的回复数；
+// 未展开或被截断的回复可以用 parent_id 再次请求，实现按线程分页
+func (s *Server) getCommentTree(c *gin.Context, postID uint) {
+	params, err := parseListParams(c, commentTreeSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	depth, err := strconv.Atoi(c.DefaultQuery("depth", "3"))
+	if err != nil || depth < 0 || depth > s.cfg.Comments.MaxTreeDepth {
+		c.Error(errValidation(FieldError{Field: "depth", Code: "range", Param: "0," + strconv.Itoa(s.cfg.Comments.MaxTreeDepth)}))
+		return
+	}
+	repliesLimit, err := strconv.Atoi(c.DefaultQuery("replies_limit", "5"))
+	if err != nil || repliesLimit < 0 || repliesLimit > maxPageSize {
+		c.Error(errValidation(FieldError{Field: "replies_limit", Code: "range", Param: "0," + strconv.Itoa(maxPageSize)}))
+		return
+	}
+
+	// 根节点：顶层评论或指定评论的直接回复
+	rootQuery := params.applyFilters(s.db.Model(&Comment{}).Where("post_id = ? AND status = ?", postID, CommentStatusApproved))
+	if parentIDStr := c.Query("parent_id"); parentIDStr != "" {
+		parentID, err := strconv.ParseUint(parentIDStr, 10, 32)
+		if err != nil {
+			c.Error(errInvalidParentID)
+			return
+		}
+		rootQuery = rootQuery.Where("parent_id = ?", parentID)
+	} else {
+		rootQuery = rootQuery.Where("parent_id IS NULL")
+	}
+
+	var total int64
+	rootQuery.Session(&gorm.Session{}).Count(&total)
+
+	// 指定 parent_id 时默认与嵌套展示的回复顺序一致（时间正序），便于接着上一页继续加载
+	if c.Query("parent_id") != "" && c.Query("order") == "" {
+		params.Desc = false
+	}
+
+	var roots []Comment
+	if err := params.applyOrder(rootQuery).Preload("User").Offset(params.Offset()).Limit(params.Limit).Find(&roots).Error; err != nil {
+		c.Error(errInternal("Failed to fetch comments", err))
+		return
+	}
+
+	nodes := make([]*CommentNode, 0, len(roots))
+	for _, comment := range roots {
+		nodes = append(nodes, newCommentNode(comment))
+	}
+
+	if err := s.loadReplies(nodes, depth, repliesLimit); err != nil {
+		c.Error(errInternal("Failed to fetch replies", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Comments retrieved successfully",
+		Data: gin.H{
+			"comments": nodes,
+			"pagination": gin.H{
+				"page":  params.Page,
+				"limit": params.Limit,
+				"total": total,
+			},
+		},
+	})
+}
+
+// loadReplies 逐层加载回复，每层只需两次查询（回复计数 + 回复内容）
+func (s *Server) loadReplies(level []*CommentNode, depth, repliesLimit int) error {
+	for ; len(level) > 0; depth-- {
+		byID := make(map[uint]*CommentNode, len(level))
+		ids := make([]uint, 0, len(level))
+		for _, node := range level {
+			byID[node.ID] = node
+			ids = append(ids, node.ID)
+		}
+
+		// 每个节点的直接回复总数
+		var counts []struct {
+			ParentID uint
+			Count    int64
+		}
+		if err := s.db.Model(&Comment{}).
+			Select("parent_id, COUNT(*) AS count").
+			Where("parent_id IN ? AND status = ?", ids, CommentStatusApproved).
+			Group("parent_id").
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+		for _, row := range counts {
+			byID[row.ParentID].ReplyCount = row.Count
+		}
+
+		if depth == 0 || repliesLimit <= 0 {
+			return nil
+		}
+
+		// 回复按时间正序展示，每个节点只保留前 repliesLimit 条
+		var replies []Comment
+		if err := s.db.Preload("User").
+			Where("parent_id IN ? AND status = ?", ids, CommentStatusApproved).
+			Order("created_at asc, id asc").
+			Find(&replies).Error; err != nil {
+			return err
+		}
+
+		var next []*CommentNode
+		for _, reply := range replies {
+			parent := byID[*reply.ParentID]
+			if len(parent.Replies) >= repliesLimit {
+				continue
+			}
+			node := newCommentNode(reply)
+			parent.Replies = append(parent.Replies, node)
+			next = append(next, node)
+		}
+		level = next
+	}
+	return nil
+}
+
+// UpdateComment 编辑评论（只有评论作者可以编辑）
+func (s *Server) UpdateComment(c *gin.Context) {
+	id := c.Param("id")
+	commentID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidCommentID)
+		return
+	}
+
+	var req UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	// 查找评论
+	var comment Comment
+	if err := s.db.First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errCommentNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch comment", err))
+		}
+		return
+	}
+
+	// 检查权限：只有作者才能编辑评论
+	userID := getCurrentUserID(c)
+	if comment.UserID != userID {
+		c.Error(errNotCommentAuthor)
+		return
+	}
+
+	// 开启审核时，编辑后的评论需要重新审核
+	updates := map[string]interface{}{
+		"content":      req.Content,
+		"content_html": renderMarkdown(req.Content),
+		"status":       s.initialCommentStatus(),
+	}
+	if err := s.db.Model(&comment).Updates(updates).Error; err != nil {
+		c.Error(errInternal("Failed to update comment", err))
+		return
+	}
+
+	// 重新查询以获取用户信息
+	s.db.Preload("User").First(&comment, comment.ID)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Comment updated successfully",
+		Data:    newCommentView(comment),
 	})
 }
+
+// DeleteComment 删除评论及其所有回复（评论作者、文章作者、版主和管理员可以删除）
+func (s *Server) DeleteComment(c *gin.Context) {
+	id := c.Param("id")
+	commentID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidCommentID)
+		return
+	}
+
+	// 查找评论及其所属文章
+	var comment Comment
+	if err := s.db.Preload("Post").First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errCommentNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch comment", err))
+		}
+		return
+	}
+
+	// 检查权限
+	userID := getCurrentUserID(c)
+	if comment.UserID != userID && comment.Post.UserID != userID && !hasRole(getCurrentRole(c), RoleModerator) {
+		c.Error(errCannotDelComment)
+		return
+	}
+
+	if err := s.transaction(func(tx *gorm.DB) error {
+		return deleteCommentThread(tx, comment.ID)
+	}); err != nil {
+		c.Error(errInternal("Failed to delete comment", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Comment deleted successfully",
+	})
+}
+
+// deleteCommentThread 删除评论及其所有回复（逐层查找子孙评论），使用同一个删除时间
+// 否则树形模式下回复的父评论不存在，回复不会再显示
+func deleteCommentThread(tx *gorm.DB, commentIDs ...uint) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	ids := slices.Clone(commentIDs)
+	for level := commentIDs; len(level) > 0; {
+		var children []uint
+		if err := tx.Model(&Comment{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+			return err
+		}
+		ids = append(ids, children...)
+		level = children
+	}
+	// 按主键逐条删除（而不是按条件批量删除），让检索索引的回调能拿到评论所属的文章
+	var comments []Comment
+	if err := tx.Where("id IN ?", ids).Find(&comments).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&comments).Error
+}
+
+// initialCommentStatus 新建或编辑评论时的审核状态
+func (s *Server) initialCommentStatus() string {
+	if s.cfg.Comments.RequireApproval {
+		return CommentStatusPending
+	}
+	return CommentStatusApproved
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDeleteCommentDeletesReplies 删除评论时所有层级的回复一起删除，其他评论不受影响
+func TestDeleteCommentDeletesReplies(t *testing.T) {
+	s, db := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Post", "content": "content"})
+	for _, body := range []gin.H{
+		{"post_id": 1, "content": "parent"},
+		{"post_id": 1, "content": "reply", "parent_id": 1},
+		{"post_id": 1, "content": "nested reply", "parent_id": 2},
+		{"post_id": 1, "content": "other"},
+	} {
+		alice.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", body)
+	}
+
+	alice.mustDo(http.StatusOK, http.MethodDelete, "/api/comments/1", nil)
+
+	var remaining []uint
+	if err := db.Model(&Comment{}).Order("id").Pluck("id", &remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0] != 4 {
+		t.Fatalf("remaining comments = %v, want [4]", remaining)
+	}
+}
+
+// TestAdminListCommentsStatus 审核队列的 status 参数只能是评论的三种状态
+func TestAdminListCommentsStatus(t *testing.T) {
+	s, db := newTestServer(t)
+	moderator := newTestClient(t, s)
+	moderator.loginAs(db, "mod", RoleModerator)
+
+	for _, status := range []string{CommentStatusApproved, CommentStatusPending, CommentStatusRejected} {
+		moderator.mustDo(http.StatusOK, http.MethodGet, "/api/admin/comments?status="+status, nil)
+	}
+	moderator.mustDo(http.StatusBadRequest, http.MethodGet, "/api/admin/comments?status=spam", nil)
+}
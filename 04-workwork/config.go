@@ -0,0 +1,596 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ModeDev 开发模式，允许使用默认JWT密钥
+	ModeDev = "dev"
+	// ModeProd 生产模式，启动时强制校验安全相关配置
+	ModeProd = "prod"
+
+	// defaultJWTSecret 默认JWT密钥，仅允许在开发模式下使用
+	defaultJWTSecret = "your_secret_key_change_in_production"
+)
+
+// Duration 支持在配置文件中以 "24h"、"15m" 形式书写的时长
+type Duration time.Duration
+
+// UnmarshalText 解析时长字符串（同时用于JSON和YAML）
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText 输出时长字符串
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// JWTConfig JWT签发配置
+// 未配置 keys 时使用 secret 作为唯一的HS256密钥；配置 keys 后由 active_key 指定签发用的密钥
+type JWTConfig struct {
+	Secret          string         `json:"secret" yaml:"secret"`
+	Issuer          string         `json:"issuer" yaml:"issuer"`     // iss，签发和校验时使用
+	Audience        string         `json:"audience" yaml:"audience"` // aud，签发和校验时使用
+	ActiveKey       string         `json:"active_key" yaml:"active_key"`
+	Keys            []JWTKeyConfig `json:"keys" yaml:"keys"`
+	TokenTTL        Duration       `json:"token_ttl" yaml:"token_ttl"`                 // 访问令牌有效期
+	RefreshTokenTTL Duration       `json:"refresh_token_ttl" yaml:"refresh_token_ttl"` // 刷新令牌有效期
+}
+
+// CommentConfig 评论配置
+type CommentConfig struct {
+	RequireApproval bool `json:"require_approval" yaml:"require_approval"` // 新评论和编辑后的评论需审核后才公开
+	MaxTreeDepth    int  `json:"max_tree_depth" yaml:"max_tree_depth"`     // 树形评论一次最多返回的回复层数
+}
+
+// SearchConfig 全文检索配置
+type SearchConfig struct {
+	Backend string `json:"backend" yaml:"backend"` // auto、mysql 或 memory
+}
+
+// PaginationConfig 列表分页配置
+type PaginationConfig struct {
+	CursorSecret string `json:"cursor_secret" yaml:"cursor_secret"` // 游标签名密钥，留空时每次启动随机生成（只允许在 dev 模式）
+}
+
+// PasswordPolicyConfig 密码策略
+type PasswordPolicyConfig struct {
+	MinLength    int    `json:"min_length" yaml:"min_length"`       // 最少字符数
+	MinClasses   int    `json:"min_classes" yaml:"min_classes"`     // 至少包含的字符种类数（小写、大写、数字、其他），0~4
+	BreachedList string `json:"breached_list" yaml:"breached_list"` // 泄露密码列表文件，每行一个明文或SHA-1摘要；留空不检查
+}
+
+// LoginThrottleConfig 登录失败限制
+// 同一用户名或IP连续失败 free_attempts 次后，每次失败需等待 base_delay 起倍增（不超过 max_delay）的时间；
+// 失败次数达到锁定阈值后锁定 lockout_duration；超过 reset_after 没有失败则重新计数
+type LoginThrottleConfig struct {
+	FreeAttempts       int      `json:"free_attempts" yaml:"free_attempts"`
+	BaseDelay          Duration `json:"base_delay" yaml:"base_delay"`
+	MaxDelay           Duration `json:"max_delay" yaml:"max_delay"`
+	LockoutThreshold   int      `json:"lockout_threshold" yaml:"lockout_threshold"`       // 同一用户名，0 表示不锁定
+	IPLockoutThreshold int      `json:"ip_lockout_threshold" yaml:"ip_lockout_threshold"` // 同一IP，0 表示不锁定
+	LockoutDuration    Duration `json:"lockout_duration" yaml:"lockout_duration"`
+	ResetAfter         Duration `json:"reset_after" yaml:"reset_after"`
+}
+
+// AuthConfig 账户安全配置
+type AuthConfig struct {
+	BcryptCost int                  `json:"bcrypt_cost" yaml:"bcrypt_cost"`
+	Password   PasswordPolicyConfig `json:"password" yaml:"password"`
+	Login      LoginThrottleConfig  `json:"login" yaml:"login"`
+
+	EmailTokenSecret string   `json:"email_token_secret" yaml:"email_token_secret"` // 邮件链接令牌的签名密钥，留空时每次启动随机生成（只允许在 dev 模式）
+	VerifyTokenTTL   Duration `json:"verify_token_ttl" yaml:"verify_token_ttl"`     // 邮箱验证链接有效期
+	ResetTokenTTL    Duration `json:"reset_token_ttl" yaml:"reset_token_ttl"`       // 重置密码链接有效期
+}
+
+// SMTPConfig SMTP服务器
+type SMTPConfig struct {
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Username string `json:"username" yaml:"username"` // 留空时不认证
+	Password string `json:"password" yaml:"password"`
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Backend   string     `json:"backend" yaml:"backend"`       // smtp、file 或 memory
+	From      string     `json:"from" yaml:"from"`             // 发件人
+	BaseURL   string     `json:"base_url" yaml:"base_url"`     // 邮件中链接的前缀（前端地址），如 https://blog.example.com
+	OutboxDir string     `json:"outbox_dir" yaml:"outbox_dir"` // file 方式写入的目录
+	SMTP      SMTPConfig `json:"smtp" yaml:"smtp"`
+}
+
+// RateLimitRule 令牌桶规则：每 per 补充 requests 个令牌，桶容量为 burst
+type RateLimitRule struct {
+	Requests int      `json:"requests" yaml:"requests"` // 0 表示该路由组不限流
+	Per      Duration `json:"per" yaml:"per"`
+	Burst    int      `json:"burst" yaml:"burst"`     // 允许的突发请求数，0 表示等于 requests
+	Methods  []string `json:"methods" yaml:"methods"` // 只限制这些请求方法，留空表示全部
+}
+
+// RateLimitConfig 接口限流配置
+type RateLimitConfig struct {
+	Enabled bool                     `json:"enabled" yaml:"enabled"`
+	Groups  map[string]RateLimitRule `json:"groups" yaml:"groups"` // 路由组名 -> 规则，api 作用于全部接口
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	Retention Duration `json:"retention" yaml:"retention"` // 删除的文章在回收站中保留的时间，超过后管理员可以彻底删除
+}
+
+// Config 博客服务配置
+// 加载优先级：默认值 < 配置文件 < 环境变量 < 命令行参数
+type Config struct {
+	Mode     string         `json:"mode" yaml:"mode"` // dev 或 prod
+	Addr     string         `json:"addr" yaml:"addr"` // 监听地址
+	Database DatabaseConfig `json:"database" yaml:"database"`
+	JWT      JWTConfig      `json:"jwt" yaml:"jwt"`
+	Comments CommentConfig  `json:"comments" yaml:"comments"`
+	Search   SearchConfig   `json:"search" yaml:"search"`
+
+	Pagination PaginationConfig `json:"pagination" yaml:"pagination"`
+	Auth       AuthConfig       `json:"auth" yaml:"auth"`
+	Mail       MailConfig       `json:"mail" yaml:"mail"`
+	RateLimit  RateLimitConfig  `json:"rate_limit" yaml:"rate_limit"`
+	Trash      TrashConfig      `json:"trash" yaml:"trash"`
+
+	// TrustedProxies 可信反向代理的IP或网段，只有来自这些地址的 X-Forwarded-For 才会被采信
+	// 留空时使用直连地址作为客户端IP（登录限制按IP计数依赖它）
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies"`
+
+	// AdminUsers 启动时授予管理员角色的用户名（只提升已存在的账号）
+	AdminUsers []string `json:"admin_users" yaml:"admin_users"`
+}
+
+// writeMethods 写操作的请求方法
+var writeMethods = []string{"POST", "PUT", "PATCH", "DELETE"}
+
+// DefaultConfig 返回默认配置（与旧版本硬编码的值一致）
+func DefaultConfig() *Config {
+	return &Config{
+		Mode: ModeDev,
+		Addr: ":8080",
+		Database: DatabaseConfig{
+			Driver: DriverMySQL, // DSN留空时使用驱动对应的默认连接
+		},
+		JWT: JWTConfig{
+			Secret:          defaultJWTSecret,
+			Issuer:          "blog-system",
+			Audience:        "blog-api",
+			TokenTTL:        Duration(15 * time.Minute),
+			RefreshTokenTTL: Duration(7 * 24 * time.Hour),
+		},
+		Comments: CommentConfig{
+			MaxTreeDepth: 5,
+		},
+		Search: SearchConfig{
+			Backend: SearchBackendAuto,
+		},
+		Auth: AuthConfig{
+			BcryptCost: 12,
+			Password: PasswordPolicyConfig{
+				MinLength:  8,
+				MinClasses: 2,
+			},
+			Login: LoginThrottleConfig{
+				FreeAttempts:       3,
+				BaseDelay:          Duration(time.Second),
+				MaxDelay:           Duration(5 * time.Minute),
+				LockoutThreshold:   10,
+				IPLockoutThreshold: 50,
+				LockoutDuration:    Duration(15 * time.Minute),
+				ResetAfter:         Duration(time.Hour),
+			},
+			VerifyTokenTTL: Duration(48 * time.Hour),
+			ResetTokenTTL:  Duration(time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Groups: map[string]RateLimitRule{
+				"api":      {Requests: 300, Per: Duration(time.Minute), Burst: 100},
+				"auth":     {Requests: 20, Per: Duration(time.Minute), Burst: 10},
+				"posts":    {Requests: 30, Per: Duration(time.Minute), Burst: 10, Methods: writeMethods},
+				"comments": {Requests: 10, Per: Duration(time.Minute), Burst: 5, Methods: writeMethods},
+			},
+		},
+		Trash: TrashConfig{
+			Retention: Duration(30 * 24 * time.Hour),
+		},
+		Mail: MailConfig{
+			Backend:   MailBackendMemory,
+			From:      "Blog <no-reply@localhost>",
+			BaseURL:   "http://localhost:8080",
+			OutboxDir: "mail-outbox",
+			SMTP: SMTPConfig{
+				Port: 587,
+			},
+		},
+	}
+}
+
+// LoadConfig 依次应用配置文件、环境变量和命令行参数，并校验最终配置
+// args 不包含程序名，通常传入 os.Args[1:]
+func LoadConfig(args []string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	fs := flag.NewFlagSet("blog-system", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("BLOG_CONFIG"), "配置文件路径（.yaml/.yml/.json）")
+	mode := fs.String("mode", "", "运行模式：dev 或 prod")
+	addr := fs.String("addr", "", "HTTP监听地址，例如 :8080")
+	dbDriver := fs.String("db-driver", "", "数据库驱动：mysql 或 sqlite")
+	dbDSN := fs.String("db-dsn", "", "数据库连接字符串")
+	requireApproval := fs.Bool("comments-require-approval", false, "评论需审核后才公开")
+	searchBackend := fs.String("search-backend", "", "全文检索后端：auto、mysql 或 memory")
+	cursorSecret := fs.String("cursor-secret", "", "分页游标签名密钥")
+	bcryptCost := fs.Int("bcrypt-cost", 0, "bcrypt 代价（4~31）")
+	breachedList := fs.String("breached-passwords", "", "泄露密码列表文件")
+	rateLimit := fs.Bool("rate-limit", true, "启用接口限流")
+	mailBackend := fs.String("mail-backend", "", "邮件发送方式：smtp、file 或 memory")
+	emailTokenSecret := fs.String("email-token-secret", "", "邮件链接令牌签名密钥")
+	trashRetention := fs.Duration("trash-retention", 0, "回收站保留期，例如 720h")
+	trustedProxies := fs.String("trusted-proxies", "", "可信反向代理的IP或网段，逗号分隔")
+	adminUsers := fs.String("admin-users", "", "管理员用户名，逗号分隔")
+	jwtSecret := fs.String("jwt-secret", "", "JWT签名密钥")
+	jwtIssuer := fs.String("jwt-issuer", "", "JWT签发者 iss")
+	jwtAudience := fs.String("jwt-audience", "", "JWT受众 aud")
+	tokenTTL := fs.Duration("token-ttl", 0, "访问令牌有效期，例如 15m")
+	refreshTokenTTL := fs.Duration("refresh-token-ttl", 0, "刷新令牌有效期，例如 168h")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// 配置文件
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// 环境变量
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	// 命令行参数（只覆盖显式指定的项）
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "mode":
+			cfg.Mode = *mode
+		case "addr":
+			cfg.Addr = *addr
+		case "db-driver":
+			cfg.Database.Driver = *dbDriver
+		case "db-dsn":
+			cfg.Database.DSN = *dbDSN
+		case "comments-require-approval":
+			cfg.Comments.RequireApproval = *requireApproval
+		case "search-backend":
+			cfg.Search.Backend = *searchBackend
+		case "cursor-secret":
+			cfg.Pagination.CursorSecret = *cursorSecret
+		case "bcrypt-cost":
+			cfg.Auth.BcryptCost = *bcryptCost
+		case "breached-passwords":
+			cfg.Auth.Password.BreachedList = *breachedList
+		case "rate-limit":
+			cfg.RateLimit.Enabled = *rateLimit
+		case "mail-backend":
+			cfg.Mail.Backend = *mailBackend
+		case "email-token-secret":
+			cfg.Auth.EmailTokenSecret = *emailTokenSecret
+		case "trash-retention":
+			cfg.Trash.Retention = Duration(*trashRetention)
+		case "trusted-proxies":
+			cfg.TrustedProxies = splitList(*trustedProxies)
+		case "admin-users":
+			cfg.AdminUsers = splitList(*adminUsers)
+		case "jwt-secret":
+			cfg.JWT.Secret = *jwtSecret
+		case "jwt-issuer":
+			cfg.JWT.Issuer = *jwtIssuer
+		case "jwt-audience":
+			cfg.JWT.Audience = *jwtAudience
+		case "token-ttl":
+			cfg.JWT.TokenTTL = Duration(*tokenTTL)
+		case "refresh-token-ttl":
+			cfg.JWT.RefreshTokenTTL = Duration(*refreshTokenTTL)
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 根据扩展名读取YAML或JSON配置文件
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".json":
+		err = json.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv 使用 BLOG_* 环境变量覆盖配置
+func (cfg *Config) applyEnv() error {
+	if v := os.Getenv("BLOG_MODE"); v != "" {
+		cfg.Mode = v
+	}
+	if v := os.Getenv("BLOG_ADDR"); v != "" {
+		cfg.Addr = v
+	}
+	if v := os.Getenv("BLOG_DB_DRIVER"); v != "" {
+		cfg.Database.Driver = v
+	}
+	if v := os.Getenv("BLOG_DB_DSN"); v != "" {
+		cfg.Database.DSN = v
+	}
+	if v := os.Getenv("BLOG_COMMENTS_REQUIRE_APPROVAL"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid BLOG_COMMENTS_REQUIRE_APPROVAL: %w", err)
+		}
+		cfg.Comments.RequireApproval = b
+	}
+	if v := os.Getenv("BLOG_SEARCH_BACKEND"); v != "" {
+		cfg.Search.Backend = v
+	}
+	if v := os.Getenv("BLOG_CURSOR_SECRET"); v != "" {
+		cfg.Pagination.CursorSecret = v
+	}
+	if v := os.Getenv("BLOG_BCRYPT_COST"); v != "" {
+		cost, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid BLOG_BCRYPT_COST: %w", err)
+		}
+		cfg.Auth.BcryptCost = cost
+	}
+	if v := os.Getenv("BLOG_BREACHED_PASSWORDS"); v != "" {
+		cfg.Auth.Password.BreachedList = v
+	}
+	if v := os.Getenv("BLOG_RATE_LIMIT_ENABLED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid BLOG_RATE_LIMIT_ENABLED: %w", err)
+		}
+		cfg.RateLimit.Enabled = b
+	}
+	if v := os.Getenv("BLOG_EMAIL_TOKEN_SECRET"); v != "" {
+		cfg.Auth.EmailTokenSecret = v
+	}
+	if v := os.Getenv("BLOG_MAIL_BACKEND"); v != "" {
+		cfg.Mail.Backend = v
+	}
+	if v := os.Getenv("BLOG_MAIL_FROM"); v != "" {
+		cfg.Mail.From = v
+	}
+	if v := os.Getenv("BLOG_MAIL_BASE_URL"); v != "" {
+		cfg.Mail.BaseURL = v
+	}
+	if v := os.Getenv("BLOG_SMTP_HOST"); v != "" {
+		cfg.Mail.SMTP.Host = v
+	}
+	if v := os.Getenv("BLOG_SMTP_PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid BLOG_SMTP_PORT: %w", err)
+		}
+		cfg.Mail.SMTP.Port = port
+	}
+	if v := os.Getenv("BLOG_SMTP_USERNAME"); v != "" {
+		cfg.Mail.SMTP.Username = v
+	}
+	if v := os.Getenv("BLOG_SMTP_PASSWORD"); v != "" {
+		cfg.Mail.SMTP.Password = v
+	}
+	if v := os.Getenv("BLOG_TRASH_RETENTION"); v != "" {
+		retention, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid BLOG_TRASH_RETENTION: %w", err)
+		}
+		cfg.Trash.Retention = Duration(retention)
+	}
+	if v := os.Getenv("BLOG_TRUSTED_PROXIES"); v != "" {
+		cfg.TrustedProxies = splitList(v)
+	}
+	if v := os.Getenv("BLOG_ADMIN_USERS"); v != "" {
+		cfg.AdminUsers = splitList(v)
+	}
+	if v := os.Getenv("BLOG_JWT_SECRET"); v != "" {
+		cfg.JWT.Secret = v
+	}
+	if v := os.Getenv("BLOG_JWT_ISSUER"); v != "" {
+		cfg.JWT.Issuer = v
+	}
+	if v := os.Getenv("BLOG_JWT_AUDIENCE"); v != "" {
+		cfg.JWT.Audience = v
+	}
+	if v := os.Getenv("BLOG_TOKEN_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid BLOG_TOKEN_TTL: %w", err)
+		}
+		cfg.JWT.TokenTTL = Duration(ttl)
+	}
+	if v := os.Getenv("BLOG_REFRESH_TOKEN_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid BLOG_REFRESH_TOKEN_TTL: %w", err)
+		}
+		cfg.JWT.RefreshTokenTTL = Duration(ttl)
+	}
+	return nil
+}
+
+// Validate 启动前校验配置
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	switch cfg.Mode {
+	case ModeDev, ModeProd:
+	default:
+		errs = append(errs, fmt.Errorf("mode must be %q or %q, got %q", ModeDev, ModeProd, cfg.Mode))
+	}
+	if cfg.Addr == "" {
+		errs = append(errs, errors.New("addr must not be empty"))
+	}
+	switch strings.ToLower(cfg.Database.Driver) {
+	case DriverMySQL, DriverSQLite, "sqlite3":
+	default:
+		errs = append(errs, fmt.Errorf("unsupported database driver %q", cfg.Database.Driver))
+	}
+	if len(cfg.JWT.Keys) == 0 {
+		if cfg.JWT.Secret == "" {
+			errs = append(errs, errors.New("jwt secret must not be empty"))
+		} else if cfg.JWT.Secret == defaultJWTSecret && cfg.Mode != ModeDev {
+			errs = append(errs, errors.New("refusing to start with the default jwt secret outside dev mode"))
+		}
+	} else {
+		if cfg.JWT.ActiveKey == "" && len(cfg.JWT.Keys) > 1 {
+			errs = append(errs, errors.New("jwt active_key is required when multiple keys are configured"))
+		}
+		for _, k := range cfg.JWT.Keys {
+			if k.Secret == defaultJWTSecret && cfg.Mode != ModeDev {
+				errs = append(errs, fmt.Errorf("refusing to start with the default secret for jwt key %q outside dev mode", k.ID))
+			}
+		}
+	}
+	switch cfg.Search.Backend {
+	case SearchBackendAuto, SearchBackendMemory:
+	case SearchBackendMySQL:
+		if strings.ToLower(cfg.Database.Driver) != DriverMySQL {
+			errs = append(errs, errors.New("search backend mysql requires the mysql database driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported search backend %q", cfg.Search.Backend))
+	}
+	if cfg.Pagination.CursorSecret == "" && cfg.Mode != ModeDev {
+		errs = append(errs, errors.New("pagination cursor_secret is required outside dev mode"))
+	}
+	if cfg.Comments.MaxTreeDepth < 1 {
+		errs = append(errs, errors.New("comments max_tree_depth must be at least 1"))
+	}
+	if cfg.Auth.BcryptCost < bcrypt.MinCost || cfg.Auth.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("auth bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if cfg.Auth.Password.MinLength < 1 || cfg.Auth.Password.MinLength > maxPasswordBytes {
+		errs = append(errs, fmt.Errorf("auth password min_length must be between 1 and %d", maxPasswordBytes))
+	}
+	if cfg.Auth.Password.MinClasses < 0 || cfg.Auth.Password.MinClasses > 4 {
+		errs = append(errs, errors.New("auth password min_classes must be between 0 and 4"))
+	}
+	if login := cfg.Auth.Login; login.FreeAttempts < 0 || login.LockoutThreshold < 0 || login.IPLockoutThreshold < 0 {
+		errs = append(errs, errors.New("auth login attempt counts must not be negative"))
+	} else if login.BaseDelay <= 0 || login.MaxDelay < login.BaseDelay {
+		errs = append(errs, errors.New("auth login base_delay must be positive and not longer than max_delay"))
+	} else if login.LockoutDuration <= 0 || login.ResetAfter <= 0 {
+		errs = append(errs, errors.New("auth login lockout_duration and reset_after must be positive"))
+	}
+	if cfg.Auth.EmailTokenSecret == "" && cfg.Mode != ModeDev {
+		errs = append(errs, errors.New("auth email_token_secret is required outside dev mode"))
+	}
+	if cfg.Auth.VerifyTokenTTL <= 0 || cfg.Auth.ResetTokenTTL <= 0 {
+		errs = append(errs, errors.New("auth verify_token_ttl and reset_token_ttl must be positive"))
+	}
+	if cfg.Trash.Retention < 0 {
+		errs = append(errs, errors.New("trash retention must not be negative"))
+	}
+	switch cfg.Mail.Backend {
+	case MailBackendMemory:
+		if cfg.Mode != ModeDev {
+			errs = append(errs, errors.New("mail backend memory does not deliver mail and is only allowed in dev mode"))
+		}
+	case MailBackendSMTP:
+		if cfg.Mail.SMTP.Host == "" || cfg.Mail.SMTP.Port <= 0 {
+			errs = append(errs, errors.New("mail smtp host and port are required for the smtp backend"))
+		}
+	case MailBackendFile:
+		if cfg.Mail.OutboxDir == "" {
+			errs = append(errs, errors.New("mail outbox_dir is required for the file backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported mail backend %q", cfg.Mail.Backend))
+	}
+	if _, err := mail.ParseAddress(cfg.Mail.From); err != nil {
+		errs = append(errs, fmt.Errorf("mail from is not a valid address: %w", err))
+	}
+	if !isHTTPURL(cfg.Mail.BaseURL) {
+		errs = append(errs, errors.New("mail base_url must be an http(s) URL"))
+	}
+	for group, rule := range cfg.RateLimit.Groups {
+		if !slices.Contains(rateLimitGroups, group) {
+			errs = append(errs, fmt.Errorf("rate_limit group %q is not one of %s", group, strings.Join(rateLimitGroups, ", ")))
+			continue
+		}
+		if rule.Requests < 0 || rule.Burst < 0 {
+			errs = append(errs, fmt.Errorf("rate_limit %s requests and burst must not be negative", group))
+		} else if rule.Requests > 0 && rule.Per <= 0 {
+			errs = append(errs, fmt.Errorf("rate_limit %s per must be positive", group))
+		}
+		for _, m := range rule.Methods {
+			if !slices.Contains([]string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}, strings.ToUpper(m)) {
+				errs = append(errs, fmt.Errorf("rate_limit %s has unsupported method %q", group, m))
+			}
+		}
+	}
+	if cfg.JWT.Issuer == "" {
+		errs = append(errs, errors.New("jwt issuer must not be empty"))
+	}
+	if cfg.JWT.Audience == "" {
+		errs = append(errs, errors.New("jwt audience must not be empty"))
+	}
+	if cfg.JWT.TokenTTL <= 0 {
+		errs = append(errs, errors.New("jwt token_ttl must be positive"))
+	}
+	if cfg.JWT.RefreshTokenTTL < cfg.JWT.TokenTTL {
+		errs = append(errs, errors.New("jwt refresh_token_ttl must not be shorter than token_ttl"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor 游标内容：定位到某一行（created_at + id），并记录翻页方向
+type pageCursor struct {
+	Scope     string    `json:"s"` // 列表所在的表，防止游标在不同列表间混用
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"i"`
+	Desc      bool      `json:"d"` // 生成游标时的排序方向
+	Before    bool      `json:"b"` // true 表示取该行之前的一页（上一页）
+}
+
+// cursorCodec 对游标进行签名和校验，客户端只能原样传回，无法伪造或修改
+type cursorCodec struct {
+	signer *signer
+}
+
+// newCursorCodec 使用配置的密钥创建游标编解码器
+// 未配置密钥时随机生成，进程重启后旧游标失效（客户端需要从第一页重新开始）
+func newCursorCodec(secret string) (*cursorCodec, error) {
+	sg, err := newSigner(secret)
+	if err != nil {
+		return nil, err
+	}
+	return &cursorCodec{signer: sg}, nil
+}
+
+// Encode 生成不透明的游标字符串
+func (cc *cursorCodec) Encode(cur pageCursor) string {
+	return cc.signer.Sign(cur)
+}
+
+// Decode 校验签名并解析游标
+func (cc *cursorCodec) Decode(token string) (*pageCursor, error) {
+	var cur pageCursor
+	if !cc.signer.Verify(token, &cur) {
+		return nil, errInvalidCursor
+	}
+	return &cur, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	// DriverMySQL MySQL数据库驱动（生产环境推荐）
+	DriverMySQL = "mysql"
+	// DriverSQLite 内嵌SQLite数据库驱动（本地开发和测试）
+	DriverSQLite = "sqlite"
+
+	defaultMySQLDSN  = "root:root@tcp(127.0.0.1:3306)/gorm?charset=utf8mb4&parseTime=True&loc=Local"
+	defaultSQLiteDSN = "blog.db"
+
+	// SQLiteMemoryDSN SQLite内存数据库，进程退出后数据即丢失
+	SQLiteMemoryDSN = ":memory:"
+)
+
+// DatabaseConfig 数据库连接配置
+type DatabaseConfig struct {
+	Driver string `json:"driver" yaml:"driver"` // mysql 或 sqlite
+	DSN    string `json:"dsn" yaml:"dsn"`       // 连接字符串，sqlite为文件路径或 :memory:
+}
+
+// dbNow 写入数据库和作为查询参数的当前时间，统一使用 UTC
+// SQLite 把时间保存为带时区的字符串并按字符串比较，存储的时间和查询参数必须使用同一时区
+func dbNow() time.Time {
+	return time.Now().UTC()
+}
+
+// openDatabase 根据配置选择驱动并打开数据库连接
+func openDatabase(cfg DatabaseConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	dsn := cfg.DSN
+
+	switch strings.ToLower(cfg.Driver) {
+	case DriverMySQL, "":
+		if dsn == "" {
+			dsn = defaultMySQLDSN
+		}
+		dialector = mysql.Open(dsn)
+	case DriverSQLite, "sqlite3":
+		if dsn == "" {
+			dsn = defaultSQLiteDSN
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+
+	conn, err := gorm.Open(dialector, &gorm.Config{NowFunc: dbNow})
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite内存数据库每个连接都是独立的库，限制为单连接保证数据可见
+	if dsn == SQLiteMemoryDSN {
+		sqlDB, err := conn.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	return conn, nil
+}
+
+// migrateDatabase 自动迁移模型，MySQL和SQLite共用同一路径
+func migrateDatabase(conn *gorm.DB) error {
+	if err := conn.AutoMigrate(&User{}, &Post{}, &PostSlug{}, &PostRevision{}, &Comment{}, &Tag{}, &Category{}, &RefreshToken{}, &RevokedToken{}, &AuditLog{}); err != nil {
+		return err
+	}
+	// 增加状态字段之前的文章都是已发布的，发布时间取创建时间
+	if err := conn.Model(&Post{}).
+		Where("status = ? AND publish_at IS NULL", PostStatusPublished).
+		UpdateColumn("publish_at", gorm.Expr("created_at")).Error; err != nil {
+		return err
+	}
+	if err := backfillPostSlugs(conn); err != nil {
+		return err
+	}
+	if err := backfillContentHTML(conn); err != nil {
+		return err
+	}
+	return backfillPostRevisions(conn)
+}
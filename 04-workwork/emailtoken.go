@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// 邮件令牌的用途，不同用途的令牌不能混用
+const (
+	EmailTokenVerify = "verify" // 验证邮箱
+	EmailTokenReset  = "reset"  // 重置密码
+)
+
+var errInvalidEmailToken = errors.New("invalid or expired email token")
+
+// emailToken 邮件链接中携带的令牌内容
+type emailToken struct {
+	Purpose   string `json:"p"`
+	UserID    uint   `json:"u"`
+	Email     string `json:"e"`           // 发送时的邮箱，邮箱修改后令牌失效
+	Stamp     string `json:"s,omitempty"` // 重置密码：当前密码哈希的指纹，密码修改后令牌失效（一次性）
+	ExpiresAt int64  `json:"x"`
+}
+
+// emailTokenCodec 对邮件令牌签名和校验，格式与分页游标相同
+type emailTokenCodec struct {
+	signer *signer
+	now    func() time.Time
+}
+
+// newEmailTokenCodec 使用配置的密钥创建编解码器
+// 未配置密钥时随机生成，进程重启后已发出的链接失效
+func newEmailTokenCodec(secret string) (*emailTokenCodec, error) {
+	sg, err := newSigner(secret)
+	if err != nil {
+		return nil, err
+	}
+	return &emailTokenCodec{signer: sg, now: time.Now}, nil
+}
+
+// passwordStamp 密码哈希的指纹，写入重置令牌
+func passwordStamp(passwordHash string) string {
+	sum := sha256.Sum256([]byte(passwordHash))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Issue 签发令牌，有效期为 ttl
+func (ec *emailTokenCodec) Issue(tok emailToken, ttl time.Duration) string {
+	tok.ExpiresAt = ec.now().Add(ttl).Unix()
+	return ec.signer.Sign(tok)
+}
+
+// Parse 校验签名、用途和有效期
+func (ec *emailTokenCodec) Parse(token, purpose string) (*emailToken, error) {
+	var tok emailToken
+	if !ec.signer.Verify(token, &tok) {
+		return nil, errInvalidEmailToken
+	}
+	if tok.Purpose != purpose || ec.now().Unix() >= tok.ExpiresAt {
+		return nil, errInvalidEmailToken
+	}
+	return &tok, nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// 错误码：客户端应根据 code 而不是 error 文本判断错误类型
+const (
+	CodeValidationFailed    = "validation_failed"     // 请求参数校验失败，details 中有逐字段说明
+	CodeInvalidBody         = "invalid_body"          // 请求体不是合法的JSON
+	CodeInvalidID           = "invalid_id"            // 路径中的ID格式错误
+	CodeInvalidParent       = "invalid_parent"        // 回复的父评论不可用
+	CodeUsernameTaken       = "username_taken"        // 用户名已存在
+	CodeEmailTaken          = "email_taken"           // 邮箱已存在
+	CodeCategoryExists      = "category_exists"       // 分类名称已存在
+	CodeInvalidCredentials  = "invalid_credentials"   // 用户名或密码错误
+	CodeWrongPassword       = "wrong_password"        // 修改账户信息时当前密码错误
+	CodeLoginThrottled      = "login_throttled"       // 登录失败过多，需等待后重试
+	CodeRateLimited         = "rate_limited"          // 请求过于频繁
+	CodeInvalidEmailToken   = "invalid_email_token"   // 邮件链接中的令牌无效、过期或已使用
+	CodeAlreadyVerified     = "already_verified"      // 邮箱已验证
+	CodeAccountLocked       = "account_locked"        // 登录失败次数达到阈值，临时锁定
+	CodePostTakenDown       = "post_taken_down"       // 文章已被管理员下架，作者不能恢复
+	CodePostNotPublished    = "post_not_published"    // 操作只允许用于已发布的文章
+	CodeIfMatchRequired     = "if_match_required"     // 修改文章时缺少 If-Match 请求头
+	CodeVersionConflict     = "version_conflict"      // If-Match 与文章当前版本不一致，data 中为当前的文章
+	CodeMissingToken        = "missing_token"         // 缺少访问令牌
+	CodeInvalidToken        = "invalid_token"         // 访问令牌无效或已过期
+	CodeTokenRevoked        = "token_revoked"         // 访问令牌已注销
+	CodeInvalidRefreshToken = "invalid_refresh_token" // 刷新令牌无效或已过期
+	CodeRefreshTokenReused  = "refresh_token_reused"  // 刷新令牌被重复使用，整个家族已吊销
+	CodeForbidden           = "forbidden"             // 没有权限
+	CodeNotFound            = "not_found"             // 资源不存在
+	CodeInternal            = "internal_error"        // 服务器内部错误
+)
+
+// APIError 带错误码和HTTP状态的错误，由 ErrorHandler 统一渲染
+type APIError struct {
+	Status  int
+	Code    string
+	Message string       // 英文消息，渲染时按语言翻译
+	Fields  []FieldError // 逐字段的校验错误
+	Data    interface{}  // 随错误返回的数据，例如版本冲突时资源的当前状态
+	Err     error        // 原始错误，只写日志，不返回给客户端
+}
+
+// FieldError 单个字段的校验错误，Message 在渲染时按语言生成
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`            // required、email、oneof、range 等
+	Param   string `json:"param,omitempty"` // 规则参数，例如 oneof 的可选值
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError 创建不带字段详情的错误
+func newAPIError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// errInternal 服务器内部错误，cause 记录到日志
+func errInternal(message string, cause error) *APIError {
+	return &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: message, Err: cause}
+}
+
+// errPostModified 文章已被其他请求修改，current 为文章的当前状态
+func errPostModified(current PostView) *APIError {
+	return &APIError{
+		Status:  http.StatusPreconditionFailed,
+		Code:    CodeVersionConflict,
+		Message: "Post has been modified by another request",
+		Data:    current,
+	}
+}
+
+// errValidation 参数校验错误
+func errValidation(fields ...FieldError) *APIError {
+	return &APIError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeValidationFailed,
+		Message: "Invalid request data",
+		Fields:  fields,
+	}
+}
+
+// 固定的业务错误
+var (
+	errInvalidPostID     = newAPIError(http.StatusBadRequest, CodeInvalidID, "Invalid post ID")
+	errInvalidCommentID  = newAPIError(http.StatusBadRequest, CodeInvalidID, "Invalid comment ID")
+	errInvalidUserID     = newAPIError(http.StatusBadRequest, CodeInvalidID, "Invalid user ID")
+	errInvalidCategoryID = newAPIError(http.StatusBadRequest, CodeInvalidID, "Invalid category ID")
+	errInvalidParentID   = newAPIError(http.StatusBadRequest, CodeInvalidID, "Invalid parent comment ID")
+	errInvalidRevision   = newAPIError(http.StatusBadRequest, CodeInvalidID, "Invalid revision number")
+	errPostNotFound      = newAPIError(http.StatusNotFound, CodeNotFound, "Post not found")
+	errPostNotInTrash    = newAPIError(http.StatusNotFound, CodeNotFound, "Post not found in trash")
+	errRevisionNotFound  = newAPIError(http.StatusNotFound, CodeNotFound, "Revision not found")
+	errCommentNotFound   = newAPIError(http.StatusNotFound, CodeNotFound, "Comment not found")
+	errCategoryNotFound  = newAPIError(http.StatusNotFound, CodeNotFound, "Category not found")
+	errUserNotFound      = newAPIError(http.StatusNotFound, CodeNotFound, "User not found")
+	errRouteNotFound     = newAPIError(http.StatusNotFound, CodeNotFound, "Resource not found")
+	errParentNotFound    = newAPIError(http.StatusBadRequest, CodeInvalidParent, "Parent comment not found")
+	errParentOtherPost   = newAPIError(http.StatusBadRequest, CodeInvalidParent, "Parent comment does not belong to this post")
+	errParentNotApproved = newAPIError(http.StatusBadRequest, CodeInvalidParent, "Cannot reply to a comment that is not approved")
+	errUsernameTaken     = newAPIError(http.StatusConflict, CodeUsernameTaken, "Username already exists")
+	errEmailTaken        = newAPIError(http.StatusConflict, CodeEmailTaken, "Email already exists")
+	errCategoryExists    = newAPIError(http.StatusConflict, CodeCategoryExists, "Category already exists")
+	errBadCredentials    = newAPIError(http.StatusUnauthorized, CodeInvalidCredentials, "Invalid username or password")
+	errWrongPassword     = newAPIError(http.StatusForbidden, CodeWrongPassword, "Current password is incorrect")
+	errBadVerifyToken    = newAPIError(http.StatusBadRequest, CodeInvalidEmailToken, "Invalid or expired verification link")
+	errBadResetToken     = newAPIError(http.StatusBadRequest, CodeInvalidEmailToken, "Invalid, expired or already used reset link")
+	errAlreadyVerified   = newAPIError(http.StatusConflict, CodeAlreadyVerified, "Email is already verified")
+	errRateLimited       = newAPIError(http.StatusTooManyRequests, CodeRateLimited, "Too many requests, please slow down")
+	errLoginThrottled    = newAPIError(http.StatusTooManyRequests, CodeLoginThrottled, "Too many failed login attempts, please try again later")
+	errAccountLocked     = newAPIError(http.StatusTooManyRequests, CodeAccountLocked, "Account temporarily locked due to too many failed login attempts")
+	errMissingToken      = newAPIError(http.StatusUnauthorized, CodeMissingToken, "Authorization header is required")
+	errBadToken          = newAPIError(http.StatusUnauthorized, CodeInvalidToken, "Invalid or expired token")
+	errBadTokenClaims    = newAPIError(http.StatusUnauthorized, CodeInvalidToken, "Invalid token claims")
+	errTokenRevoked      = newAPIError(http.StatusUnauthorized, CodeTokenRevoked, "Token has been revoked")
+	errBadRefreshToken   = newAPIError(http.StatusUnauthorized, CodeInvalidRefreshToken, "Invalid or expired refresh token")
+	errUsedRefreshToken  = newAPIError(http.StatusUnauthorized, CodeRefreshTokenReused, "Refresh token has already been used")
+	errNoPermission      = newAPIError(http.StatusForbidden, CodeForbidden, "Insufficient permissions")
+	errCannotUpdatePost  = newAPIError(http.StatusForbidden, CodeForbidden, "You can only update your own posts")
+	errCannotDeletePost  = newAPIError(http.StatusForbidden, CodeForbidden, "You can only delete your own posts")
+	errCannotRestorePost = newAPIError(http.StatusForbidden, CodeForbidden, "You can only restore your own posts")
+	errPostTakenDown     = newAPIError(http.StatusForbidden, CodePostTakenDown, "Post was taken down by an administrator and cannot be restored")
+	errNotCommentAuthor  = newAPIError(http.StatusForbidden, CodeForbidden, "You can only update your own comments")
+	errCannotDelComment  = newAPIError(http.StatusForbidden, CodeForbidden, "You can only delete your own comments or comments on your posts")
+	errCannotArchivePost = newAPIError(http.StatusConflict, CodePostNotPublished, "Only published posts can be archived")
+	errCommentsClosed    = newAPIError(http.StatusConflict, CodePostNotPublished, "Comments are only allowed on published posts")
+	errIfMatchRequired   = newAPIError(http.StatusPreconditionRequired, CodeIfMatchRequired, "If-Match header is required")
+	errOwnRole           = newAPIError(http.StatusForbidden, CodeForbidden, "You cannot change your own role")
+)
+
+func init() {
+	// 校验错误中的字段名使用JSON名称，与请求体保持一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+		v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+			return validUsername(fl.Field().String())
+		})
+	}
+}
+
+// bindError 把 ShouldBindJSON 的错误转换为逐字段的校验错误
+func bindError(err error) *APIError {
+	var (
+		verrs   validator.ValidationErrors
+		typeErr *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &verrs):
+		fields := make([]FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, FieldError{Field: fe.Field(), Code: fe.Tag(), Param: fe.Param()})
+		}
+		return errValidation(fields...)
+	case errors.As(err, &typeErr):
+		return errValidation(FieldError{Field: typeErr.Field, Code: "type", Param: typeErr.Type.String()})
+	case errors.Is(err, io.EOF):
+		return &APIError{Status: http.StatusBadRequest, Code: CodeInvalidBody, Message: "Request body is required", Err: err}
+	default:
+		return &APIError{Status: http.StatusBadRequest, Code: CodeInvalidBody, Message: "Request body must be valid JSON", Err: err}
+	}
+}
+
+// ErrorHandler 统一渲染处理函数通过 c.Error 记录的错误
+// 默认使用 APIResponse 格式；请求头 Accept 包含 application/problem+json 时使用 RFC 7807 格式
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			apiErr = errInternal("Internal server error", err)
+		}
+		if apiErr.Status >= http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, apiErr)
+		}
+
+		lang := requestLanguage(c)
+		message := translate(lang, apiErr.Message)
+		fields := make([]FieldError, len(apiErr.Fields))
+		for i, fe := range apiErr.Fields {
+			fe.Message = fieldMessage(lang, fe)
+			fields[i] = fe
+		}
+
+		if strings.Contains(c.GetHeader("Accept"), "application/problem+json") {
+			c.Render(apiErr.Status, problemJSON{ProblemDetails{
+				Type:     "about:blank",
+				Title:    http.StatusText(apiErr.Status),
+				Status:   apiErr.Status,
+				Detail:   message,
+				Instance: c.Request.URL.Path,
+				Code:     apiErr.Code,
+				Errors:   fields,
+				Data:     apiErr.Data,
+			}})
+			return
+		}
+
+		// error 保留完整的可读文本，兼容只读取 error 的旧客户端
+		text := message
+		if len(fields) > 0 {
+			parts := make([]string, len(fields))
+			for i, fe := range fields {
+				parts[i] = fe.Message
+			}
+			text += ": " + strings.Join(parts, "; ")
+		}
+		c.JSON(apiErr.Status, APIResponse{
+			Success: false,
+			Data:    apiErr.Data,
+			Error:   text,
+			Code:    apiErr.Code,
+			Details: fields,
+		})
+	}
+}
+
+// recoveryHandler 把 panic 转换为统一格式的500错误
+func recoveryHandler(c *gin.Context, recovered interface{}) {
+	c.Error(errInternal("Internal server error", fmt.Errorf("panic: %v", recovered)))
+	c.Abort()
+}
+
+// ProblemDetails RFC 7807 错误响应
+type ProblemDetails struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail"`
+	Instance string       `json:"instance"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	Data     interface{}  `json:"data,omitempty"`
+}
+
+// problemJSON 以 application/problem+json 渲染
+type problemJSON struct {
+	problem ProblemDetails
+}
+
+func (r problemJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.problem)
+}
+
+func (r problemJSON) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errStalePost 按版本号条件更新文章时没有更新到任何行，说明文章在读取之后已被修改
+var errStalePost = errors.New("post version changed")
+
+// postETag 文章的 ETag，由版本号生成，文章每次修改后都会变化
+func postETag(post Post) string {
+	return fmt.Sprintf(`"v%d"`, post.Version)
+}
+
+// etagMatches If-Match 请求头是否与 etag 一致，支持逗号分隔的多个值和 "*"
+// If-Match 使用强比较，弱 ETag（W/ 前缀）不匹配
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPostIfMatch 校验修改文章的请求携带的 If-Match 请求头
+// 缺少时返回 428；与当前版本不一致时返回 412，响应中附带文章的当前内容和 ETag
+func (s *Server) checkPostIfMatch(c *gin.Context, post Post) error {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return errIfMatchRequired
+	}
+	if !etagMatches(header, postETag(post)) {
+		return s.postModified(c, post.ID)
+	}
+	return nil
+}
+
+// postModified 生成版本冲突错误：重新读取文章，响应中返回当前的内容和 ETag
+func (s *Server) postModified(c *gin.Context, postID uint) error {
+	var post Post
+	if err := s.postDetailQuery().First(&post, postID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// 文章在此期间被删除
+			return errPostNotFound
+		}
+		return errInternal("Failed to fetch post", err)
+	}
+	c.Header("ETag", postETag(post))
+	return errPostModified(newPostView(post))
+}
+
+// bumpPostVersion 以读取时的版本号为条件把文章的版本号加1，并同时写入 updates
+// 文章在读取之后已被其他请求修改时返回 errStalePost
+func bumpPostVersion(tx *gorm.DB, post *Post, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = make(map[string]interface{}, 1)
+	}
+	updates["version"] = gorm.Expr("version + 1")
+	result := tx.Model(post).Where("version = ?", post.Version).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errStalePost
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPostVersionBumpedByTrash 删除、下架和从回收站恢复都会让文章的版本号加1，之前的 ETag 失效
+func TestPostVersionBumpedByTrash(t *testing.T) {
+	s, db := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+
+	admin := newTestClient(t, s)
+	admin.loginAs(db, "root", RoleAdmin)
+
+	version := func(id uint) int {
+		var post Post
+		if err := db.Unscoped().First(&post, id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return post.Version
+	}
+
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "One", "content": "content"})
+	alice.mustDo(http.StatusOK, http.MethodDelete, "/api/posts/1", nil, "If-Match", `"v1"`)
+	if got := version(1); got != 2 {
+		t.Fatalf("version after delete = %d, want 2", got)
+	}
+	rec := alice.mustDo(http.StatusOK, http.MethodPost, "/api/posts/1/restore", nil)
+	if got, etag := version(1), rec.Header().Get("ETag"); got != 3 || etag != `"v3"` {
+		t.Fatalf("after restore version = %d, ETag = %s, want 3 and \"v3\"", got, etag)
+	}
+	alice.mustDo(http.StatusPreconditionFailed, http.MethodPut, "/api/posts/1", gin.H{"title": "x"}, "If-Match", `"v2"`)
+
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Two", "content": "content"})
+	admin.mustDo(http.StatusOK, http.MethodDelete, "/api/admin/posts/2", nil)
+	if got := version(2); got != 2 {
+		t.Fatalf("version after takedown = %d, want 2", got)
+	}
+}
+
+// TestUpdatePostWithoutChanges 没有提供任何字段的修改请求不改变版本号，返回当前的 ETag
+func TestUpdatePostWithoutChanges(t *testing.T) {
+	s, _ := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "One", "content": "content"})
+	rec := alice.mustDo(http.StatusOK, http.MethodPut, "/api/posts/1", gin.H{}, "If-Match", `"v1"`)
+	if etag := rec.Header().Get("ETag"); etag != `"v1"` {
+		t.Fatalf("ETag after empty update = %s, want \"v1\"", etag)
+	}
+	alice.mustDo(http.StatusOK, http.MethodPut, "/api/posts/1", gin.H{"title": "Two"}, "If-Match", `"v1"`)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var updateGolden = flag.Bool("update", false, "用当前的响应重新生成 testdata/golden 下的快照")
+
+// goldenHeaders 写入快照的响应头
+var goldenHeaders = []string{"ETag", "Location"}
+
+// volatileFields 每次运行都会变化的字段，写入快照前替换为占位符
+var volatileFields = map[string]string{
+	"created_at":    "<time>",
+	"updated_at":    "<time>",
+	"publish_at":    "<time>",
+	"deleted_at":    "<time>",
+	"purge_at":      "<time>",
+	"expires_at":    "<time>",
+	"token":         "<token>",
+	"refresh_token": "<token>",
+	"next_cursor":   "<cursor>",
+	"prev_cursor":   "<cursor>",
+}
+
+// normalizeJSON 把变化的字段替换为占位符（null 保持不变，便于区分有无值）
+func normalizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if placeholder, ok := volatileFields[key]; ok && value != nil {
+				v[key] = placeholder
+				continue
+			}
+			v[key] = normalizeJSON(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeJSON(value)
+		}
+	}
+	return v
+}
+
+// assertGolden 比较响应与 testdata/golden/<name>.json，-update 时重新生成
+func assertGolden(t *testing.T, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	// 重定向等响应的正文不是 JSON，按原文保存
+	var body interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		body = rec.Body.String()
+	}
+	snapshot := map[string]interface{}{
+		"status": rec.Code,
+		"body":   normalizeJSON(body),
+	}
+	headers := map[string]string{}
+	for _, key := range goldenHeaders {
+		if value := rec.Header().Get(key); value != "" {
+			headers[key] = value
+		}
+	}
+	if len(headers) > 0 {
+		snapshot["headers"] = headers
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(snapshot); err != nil {
+		t.Fatalf("%s: encode snapshot: %v", name, err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: %v（使用 go test -run TestGoldenResponses -update 生成）", name, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: response does not match %s\n--- got\n%s\n--- want\n%s", name, path, got, want)
+	}
+}
+
+// TestGoldenResponses 按顺序调用各个接口，每个接口的响应与一个快照文件比较
+// 接口返回结构的任何变化都会在这里暴露，确认是有意的修改后用 -update 更新快照
+func TestGoldenResponses(t *testing.T) {
+	s, _ := newTestServer(t)
+	alice := newTestClient(t, s)
+	bob := newTestClient(t, s)
+	anon := newTestClient(t, s)
+
+	s.db.Create(&Category{Name: "tech", Description: "Technology"})
+
+	steps := []struct {
+		name   string
+		client *testClient
+		method string
+		path   string
+		body   interface{}
+		header []string
+	}{
+		{"auth_register", anon, http.MethodPost, "/api/auth/register", gin.H{"username": "carol", "email": "carol@example.com", "password": "Passw0rd!x"}, nil},
+		{"auth_register_duplicate", anon, http.MethodPost, "/api/auth/register", gin.H{"username": "carol", "email": "other@example.com", "password": "Passw0rd!x"}, nil},
+		{"auth_register_invalid", anon, http.MethodPost, "/api/auth/register", gin.H{"username": "dave", "email": "not-an-email", "password": "x"}, nil},
+		{"auth_login_failed", anon, http.MethodPost, "/api/auth/login", gin.H{"username": "carol", "password": "wrong"}, nil},
+
+		{"posts_create", alice, http.MethodPost, "/api/posts", gin.H{"title": "Hello World", "content": "**Hello** <script>x</script>", "tags": []string{"Go", "web"}, "categories": []string{"tech"}}, nil},
+		{"posts_create_draft", alice, http.MethodPost, "/api/posts", gin.H{"title": "草稿", "content": "draft", "status": "draft"}, nil},
+		{"posts_create_unauthenticated", anon, http.MethodPost, "/api/posts", gin.H{"title": "x", "content": "x"}, nil},
+		{"posts_create_invalid", alice, http.MethodPost, "/api/posts", gin.H{"title": ""}, nil},
+		{"posts_list", anon, http.MethodGet, "/api/posts", nil, nil},
+		{"posts_list_fields", anon, http.MethodGet, "/api/posts?fields=id,title,comment_count&tag=go", nil, nil},
+		{"posts_get", anon, http.MethodGet, "/api/posts/1", nil, nil},
+		{"posts_get_draft_hidden", anon, http.MethodGet, "/api/posts/2", nil, nil},
+		{"posts_get_not_found", anon, http.MethodGet, "/api/posts/99", nil, nil},
+		{"posts_get_invalid_id", anon, http.MethodGet, "/api/posts/abc", nil, nil},
+		{"posts_get_by_slug", anon, http.MethodGet, "/api/posts/by-slug/hello-world", nil, nil},
+		{"posts_update_missing_if_match", alice, http.MethodPut, "/api/posts/1", gin.H{"title": "x"}, nil},
+		{"posts_update_forbidden", bob, http.MethodPut, "/api/posts/1", gin.H{"title": "x"}, []string{"If-Match", `"v1"`}},
+		{"posts_update", alice, http.MethodPut, "/api/posts/1", gin.H{"title": "Hello Again", "tags": []string{"go"}}, []string{"If-Match", `"v1"`}},
+		{"posts_update_stale", alice, http.MethodPut, "/api/posts/1", gin.H{"title": "x"}, []string{"If-Match", `"v1"`}},
+		{"posts_get_by_old_slug", anon, http.MethodGet, "/api/posts/by-slug/hello-world", nil, nil},
+		{"posts_search", anon, http.MethodGet, "/api/posts/search?q=hello", nil, nil},
+		{"posts_revisions", anon, http.MethodGet, "/api/posts/1/revisions", nil, nil},
+		{"posts_revisions_diff", anon, http.MethodGet, "/api/posts/1/revisions/diff", nil, nil},
+
+		{"comments_create", bob, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "Nice *post*"}, nil},
+		{"comments_reply", alice, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "Thanks", "parent_id": 1}, nil},
+		{"comments_create_on_draft", bob, http.MethodPost, "/api/comments", gin.H{"post_id": 2, "content": "hi"}, nil},
+		{"comments_list", anon, http.MethodGet, "/api/comments/post/1", nil, nil},
+		{"comments_tree", anon, http.MethodGet, "/api/comments/post/1?mode=tree", nil, nil},
+		{"comments_update", bob, http.MethodPut, "/api/comments/1", gin.H{"content": "Great post"}, nil},
+		{"comments_update_forbidden", alice, http.MethodPut, "/api/comments/1", gin.H{"content": "hijack"}, nil},
+		{"comments_delete", alice, http.MethodDelete, "/api/comments/2", nil, nil},
+
+		{"users_me", alice, http.MethodGet, "/api/users/me", nil, nil},
+		{"users_me_posts", alice, http.MethodGet, "/api/users/me/posts", nil, nil},
+		{"users_get", anon, http.MethodGet, "/api/users/1", nil, nil},
+		{"tags_list", anon, http.MethodGet, "/api/tags", nil, nil},
+		{"categories_list", anon, http.MethodGet, "/api/categories", nil, nil},
+
+		{"posts_delete", alice, http.MethodDelete, "/api/posts/1", nil, []string{"If-Match", `"v2"`}},
+		{"posts_trash", alice, http.MethodGet, "/api/posts/trash", nil, nil},
+		{"posts_restore", alice, http.MethodPost, "/api/posts/1/restore", nil, nil},
+		{"route_not_found", anon, http.MethodGet, "/api/nope", nil, nil},
+	}
+
+	// 登录响应也作为快照，之后的请求使用各自的令牌
+	assertGolden(t, "auth_login", alice.login("alice"))
+	bob.login("bob")
+
+	for _, step := range steps {
+		rec := step.client.do(step.method, step.path, step.body, step.header...)
+		assertGolden(t, step.name, rec)
+	}
+
+	t.Run("refresh_and_logout", func(t *testing.T) {
+		client := newTestClient(t, s)
+		rec := client.login("erin")
+		var login struct {
+			Data struct {
+				RefreshToken string `json:"refresh_token"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &login); err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, "auth_refresh", client.do(http.MethodPost, "/api/auth/refresh", gin.H{"refresh_token": login.Data.RefreshToken}))
+		assertGolden(t, "auth_refresh_reused", client.do(http.MethodPost, "/api/auth/refresh", gin.H{"refresh_token": login.Data.RefreshToken}))
+		assertGolden(t, "auth_logout", client.do(http.MethodPost, "/api/auth/logout", nil))
+		assertGolden(t, "auth_logout_revoked", client.do(http.MethodGet, "/api/users/me", nil))
+	})
+}
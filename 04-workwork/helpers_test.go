@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testConfig 测试用配置：SQLite 内存数据库、进程内检索、最低 bcrypt 代价，关闭限流
+func testConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.Database = DatabaseConfig{Driver: DriverSQLite, DSN: SQLiteMemoryDSN}
+	cfg.Search.Backend = SearchBackendMemory
+	cfg.Pagination.CursorSecret = "test-cursor-secret"
+	cfg.Auth.BcryptCost = 4
+	cfg.Auth.EmailTokenSecret = "test-email-token-secret"
+	cfg.RateLimit.Enabled = false
+	return cfg
+}
+
+// newTestServer 创建连接独立内存数据库的服务，每次调用的数据互不影响
+func newTestServer(tb testing.TB) (*Server, *gorm.DB) {
+	tb.Helper()
+	cfg := testConfig()
+	db, err := openDatabase(cfg.Database)
+	if err != nil {
+		tb.Fatalf("open database: %v", err)
+	}
+	db.Logger = logger.Discard
+	if err := migrateDatabase(db); err != nil {
+		tb.Fatalf("migrate database: %v", err)
+	}
+	s, err := NewServer(cfg, db)
+	if err != nil {
+		tb.Fatalf("new server: %v", err)
+	}
+	return s, db
+}
+
+// testClient 通过 httptest 调用服务的路由，登录后自动携带访问令牌
+type testClient struct {
+	tb     testing.TB
+	router http.Handler
+	token  string
+}
+
+// newTestClient 创建未登录的客户端
+func newTestClient(tb testing.TB, s *Server) *testClient {
+	return &testClient{tb: tb, router: s.Router()}
+}
+
+// do 发送请求，body 不为 nil 时编码为 JSON；headers 为键值对
+func (c *testClient) do(method, path string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	c.tb.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.tb.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	c.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// mustDo 发送请求并检查状态码
+func (c *testClient) mustDo(status int, method, path string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	c.tb.Helper()
+	rec := c.do(method, path, body, headers...)
+	if rec.Code != status {
+		c.tb.Fatalf("%s %s: status %d, want %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	return rec
+}
+
+// login 注册并登录用户，之后的请求使用该用户的访问令牌
+func (c *testClient) login(username string) *httptest.ResponseRecorder {
+	c.tb.Helper()
+	c.register(username)
+	return c.signIn(username)
+}
+
+// loginAs 注册用户并授予角色后登录（角色在登录时写入令牌）
+func (c *testClient) loginAs(db *gorm.DB, username, role string) *httptest.ResponseRecorder {
+	c.tb.Helper()
+	c.register(username)
+	if err := db.Model(&User{}).Where("username = ?", username).Update("role", role).Error; err != nil {
+		c.tb.Fatalf("set role: %v", err)
+	}
+	return c.signIn(username)
+}
+
+// register 注册用户，密码统一为 Passw0rd!x
+func (c *testClient) register(username string) {
+	c.tb.Helper()
+	c.mustDo(http.StatusCreated, http.MethodPost, "/api/auth/register", gin.H{
+		"username": username,
+		"email":    username + "@example.com",
+		"password": "Passw0rd!x",
+	})
+}
+
+// signIn 登录并保存访问令牌
+func (c *testClient) signIn(username string) *httptest.ResponseRecorder {
+	c.tb.Helper()
+	rec := c.mustDo(http.StatusOK, http.MethodPost, "/api/auth/login", gin.H{
+		"username": username,
+		"password": "Passw0rd!x",
+	})
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		c.tb.Fatalf("decode login response: %v", err)
+	}
+	c.token = resp.Data.Token
+	return rec
+}
+
+// withLocalZone 在测试期间把本地时区改为 offset（秒）对应的固定时区，验证时间的存储和比较不依赖服务器时区
+func withLocalZone(tb testing.TB, offset int) {
+	tb.Helper()
+	saved := time.Local
+	time.Local = time.FixedZone("TEST", offset)
+	tb.Cleanup(func() { time.Local = saved })
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的错误消息语言
+const (
+	LangEN = "en"
+	LangZH = "zh"
+)
+
+// requestLanguage 根据 Accept-Language 选择错误消息语言，默认英文
+func requestLanguage(c *gin.Context) string {
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch {
+		case strings.HasPrefix(strings.ToLower(tag), "zh"):
+			return LangZH
+		case strings.HasPrefix(strings.ToLower(tag), "en"):
+			return LangEN
+		}
+	}
+	return LangEN
+}
+
+// messagesZH 错误消息的中文翻译，以英文消息为键
+var messagesZH = map[string]string{
+	"Invalid request data":            "请求参数不合法",
+	"Request body is required":        "请求体不能为空",
+	"Request body must be valid JSON": "请求体必须是合法的JSON",
+	"Internal server error":           "服务器内部错误",
+	"Resource not found":              "资源不存在",
+
+	"Invalid post ID":           "文章ID格式错误",
+	"Invalid comment ID":        "评论ID格式错误",
+	"Invalid user ID":           "用户ID格式错误",
+	"Invalid parent comment ID": "父评论ID格式错误",
+	"Invalid category ID":       "分类ID格式错误",
+	"Invalid revision number":   "版本号格式错误",
+	"Post not found":            "文章不存在",
+	"Post not found in trash":   "回收站中没有这篇文章",
+	"Revision not found":        "历史版本不存在",
+	"Comment not found":         "评论不存在",
+	"User not found":            "用户不存在",
+	"Category not found":        "分类不存在",
+
+	"Parent comment not found":                       "父评论不存在",
+	"Parent comment does not belong to this post":    "父评论不属于这篇文章",
+	"Cannot reply to a comment that is not approved": "不能回复未通过审核的评论",
+
+	"Username already exists":             "用户名已存在",
+	"Email already exists":                "邮箱已存在",
+	"Category already exists":             "分类已存在",
+	"Invalid username or password":        "用户名或密码错误",
+	"Current password is incorrect":       "当前密码错误",
+	"Authorization header is required":    "缺少 Authorization 请求头",
+	"If-Match header is required":         "缺少 If-Match 请求头",
+	"Invalid or expired token":            "令牌无效或已过期",
+	"Invalid token claims":                "令牌内容无效",
+	"Token has been revoked":              "令牌已注销",
+	"Invalid or expired refresh token":    "刷新令牌无效或已过期",
+	"Refresh token has already been used": "刷新令牌已被使用",
+
+	"Too many failed login attempts, please try again later":           "登录失败次数过多，请稍后再试",
+	"Account temporarily locked due to too many failed login attempts": "登录失败次数过多，账户已临时锁定",
+	"Too many requests, please slow down":                              "请求过于频繁，请稍后再试",
+
+	"Invalid or expired verification link":        "验证链接无效或已过期",
+	"Invalid, expired or already used reset link": "重置链接无效、已过期或已使用",
+	"Email is already verified":                   "邮箱已验证",
+
+	"You can only restore your own posts":                            "只能恢复自己的文章",
+	"Only published posts can be archived":                           "只能归档已发布的文章",
+	"Comments are only allowed on published posts":                   "只能评论已发布的文章",
+	"Post was taken down by an administrator and cannot be restored": "文章已被管理员下架，无法恢复",
+	"Post has been modified by another request":                      "文章已被其他请求修改",
+
+	"Insufficient permissions":                                        "权限不足",
+	"You can only update your own posts":                              "只能修改自己的文章",
+	"You can only delete your own posts":                              "只能删除自己的文章",
+	"You can only update your own comments":                           "只能修改自己的评论",
+	"You can only delete your own comments or comments on your posts": "只能删除自己的评论或自己文章下的评论",
+	"You cannot change your own role":                                 "不能修改自己的角色",
+
+	"Failed to fetch posts":           "获取文章列表失败",
+	"Failed to fetch post":            "获取文章失败",
+	"Failed to create post":           "创建文章失败",
+	"Failed to update post":           "更新文章失败",
+	"Failed to delete post":           "删除文章失败",
+	"Failed to search posts":          "检索文章失败",
+	"Failed to fetch comments":        "获取评论列表失败",
+	"Failed to fetch comment":         "获取评论失败",
+	"Failed to fetch parent comment":  "获取父评论失败",
+	"Failed to fetch replies":         "获取回复失败",
+	"Failed to create comment":        "创建评论失败",
+	"Failed to update comment":        "更新评论失败",
+	"Failed to update comment status": "更新评论状态失败",
+	"Failed to delete comment":        "删除评论失败",
+	"Failed to fetch users":           "获取用户列表失败",
+	"Failed to fetch user":            "获取用户失败",
+	"Failed to update user role":      "更新用户角色失败",
+	"Failed to update user":           "更新用户信息失败",
+	"Failed to change password":       "修改密码失败",
+	"Failed to delete account":        "注销账户失败",
+	"Failed to hash password":         "密码加密失败",
+	"Failed to create user":           "创建用户失败",
+	"Failed to generate token":        "生成令牌失败",
+	"Failed to verify token":          "校验令牌失败",
+	"Failed to refresh token":         "刷新令牌失败",
+	"Failed to logout":                "注销失败",
+	"Failed to fetch audit logs":      "获取审计记录失败",
+	"Failed to send email":            "发送邮件失败",
+	"Failed to verify email":          "验证邮箱失败",
+	"Failed to reset password":        "重置密码失败",
+	"Failed to fetch trash":           "获取回收站失败",
+	"Failed to restore post":          "恢复文章失败",
+	"Failed to purge trash":           "清理回收站失败",
+	"Failed to fetch tags":            "获取标签列表失败",
+	"Failed to fetch categories":      "获取分类列表失败",
+	"Failed to create category":       "创建分类失败",
+	"Failed to delete category":       "删除分类失败",
+	"Failed to fetch revisions":       "获取历史版本失败",
+	"Failed to restore revision":      "恢复历史版本失败",
+}
+
+// translate 翻译错误消息，没有翻译时返回英文原文
+func translate(lang, message string) string {
+	if lang == LangZH {
+		if zh, ok := messagesZH[message]; ok {
+			return zh
+		}
+	}
+	return message
+}
+
+// fieldMessages 字段校验规则对应的说明，%[1]s 为字段名，%[2]s 起为规则参数
+var fieldMessages = map[string]map[string]string{
+	LangEN: {
+		"required":        "%[1]s is required",
+		"email":           "%[1]s must be a valid email address",
+		"url":             "%[1]s must be a valid URL",
+		"min":             "%[1]s must be at least %[2]s characters long",
+		"max":             "%[1]s must be at most %[2]s characters long",
+		"gte":             "%[1]s must be at least %[2]s",
+		"lte":             "%[1]s must be at most %[2]s",
+		"oneof":           "%[1]s must be one of: %[2]s",
+		"range":           "%[1]s must be between %[2]s and %[3]s",
+		"integer":         "%[1]s must be an integer",
+		"datetime":        "%[1]s must be a date (YYYY-MM-DD) or an RFC 3339 time",
+		"type":            "%[1]s must be of type %[2]s",
+		"not_after":       "%[1]s must not be later than %[2]s",
+		"conflict":        "%[1]s cannot be combined with %[2]s",
+		"unsupported":     "%[1]s is not supported for this endpoint",
+		"cursor_mismatch": "%[1]s does not match the cursor",
+		"invalid":         "%[1]s is invalid",
+		"future":          "%[1]s must be in the future",
+		"max_items":       "%[1]s must contain at most %[2]s items",
+		"unknown":         "%[1]s contains an unknown value: %[2]s",
+		"username":        "%[1]s may only contain letters, digits, '_', '-' and '.'",
+
+		// 密码策略
+		"max_bytes":         "%[1]s must be at most %[2]s bytes long",
+		"password_classes":  "%[1]s must contain at least %[2]s of: lowercase letters, uppercase letters, digits, other characters",
+		"password_breached": "%[1]s has appeared in a data breach, please choose another one",
+	},
+	LangZH: {
+		"required":        "%[1]s 不能为空",
+		"email":           "%[1]s 必须是有效的邮箱地址",
+		"url":             "%[1]s 必须是有效的URL",
+		"min":             "%[1]s 长度不能少于 %[2]s 个字符",
+		"max":             "%[1]s 长度不能超过 %[2]s 个字符",
+		"gte":             "%[1]s 不能小于 %[2]s",
+		"lte":             "%[1]s 不能大于 %[2]s",
+		"oneof":           "%[1]s 必须是以下值之一：%[2]s",
+		"range":           "%[1]s 必须在 %[2]s 到 %[3]s 之间",
+		"integer":         "%[1]s 必须是整数",
+		"datetime":        "%[1]s 必须是日期（YYYY-MM-DD）或 RFC 3339 时间",
+		"type":            "%[1]s 必须是 %[2]s 类型",
+		"not_after":       "%[1]s 不能晚于 %[2]s",
+		"conflict":        "%[1]s 不能与 %[2]s 同时使用",
+		"unsupported":     "此接口不支持 %[1]s",
+		"cursor_mismatch": "%[1]s 与游标不一致",
+		"invalid":         "%[1]s 无效",
+		"future":          "%[1]s 必须晚于当前时间",
+		"max_items":       "%[1]s 最多包含 %[2]s 项",
+		"unknown":         "%[1]s 包含不存在的值：%[2]s",
+		"username":        "%[1]s 只能包含字母、数字、下划线、连字符和点",
+
+		// 密码策略
+		"max_bytes":         "%[1]s 长度不能超过 %[2]s 个字节",
+		"password_classes":  "%[1]s 至少需要包含小写字母、大写字母、数字、其他字符中的 %[2]s 种",
+		"password_breached": "%[1]s 出现在已泄露的密码库中，请更换",
+	},
+}
+
+// fieldMessage 生成字段错误的说明，未知规则按 invalid 处理
+func fieldMessage(lang string, fe FieldError) string {
+	messages, ok := fieldMessages[lang]
+	if !ok {
+		messages = fieldMessages[LangEN]
+	}
+	format, ok := messages[fe.Code]
+	if !ok {
+		format = messages["invalid"]
+	}
+
+	// 格式串都使用显式下标，多余的参数不会出现在结果中
+	args := []interface{}{fe.Field}
+	switch fe.Code {
+	case "oneof":
+		args = append(args, strings.Join(strings.Fields(fe.Param), ", "))
+	case "range":
+		lo, hi, _ := strings.Cut(fe.Param, ",")
+		args = append(args, lo, hi)
+	default:
+		args = append(args, fe.Param)
+	}
+	return fmt.Sprintf(format, args...)
+}
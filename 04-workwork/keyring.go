@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// AlgHS256 HMAC-SHA256 对称密钥
+	AlgHS256 = "HS256"
+	// AlgRS256 RSA-SHA256 非对称密钥
+	AlgRS256 = "RS256"
+	// AlgEdDSA Ed25519 非对称密钥
+	AlgEdDSA = "EdDSA"
+
+	// legacyKeyID 未配置 jwt.keys 时由 jwt.secret 生成的HS256密钥ID
+	legacyKeyID = "default"
+)
+
+// JWTKeyConfig 单个签名密钥的配置
+// 非对称密钥只配置公钥时仅用于验签，适合密钥轮换后保留旧公钥直到旧令牌全部过期
+type JWTKeyConfig struct {
+	ID             string `json:"id" yaml:"id"`               // 写入令牌头部的 kid
+	Algorithm      string `json:"algorithm" yaml:"algorithm"` // HS256、RS256 或 EdDSA
+	Secret         string `json:"secret" yaml:"secret"`       // HS256 密钥
+	PrivateKeyFile string `json:"private_key_file" yaml:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file" yaml:"public_key_file"`
+}
+
+// signingKey 解析后的密钥
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{} // 为空表示仅用于验签
+	verifyKey interface{}
+}
+
+// KeyRing 按 kid 管理多把签名密钥：用当前密钥签发，用任意已配置的密钥验签
+type KeyRing struct {
+	active  *signingKey
+	keys    map[string]*signingKey
+	methods []string
+}
+
+// NewKeyRing 根据JWT配置加载密钥
+func NewKeyRing(cfg JWTConfig) (*KeyRing, error) {
+	keyConfigs := cfg.Keys
+	activeID := cfg.ActiveKey
+	if len(keyConfigs) == 0 {
+		keyConfigs = []JWTKeyConfig{{ID: legacyKeyID, Algorithm: AlgHS256, Secret: cfg.Secret}}
+		activeID = legacyKeyID
+	}
+
+	ring := &KeyRing{keys: make(map[string]*signingKey)}
+	seenMethods := make(map[string]bool)
+	for _, kc := range keyConfigs {
+		key, err := loadSigningKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.ID, err)
+		}
+		if _, dup := ring.keys[key.id]; dup {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.id)
+		}
+		ring.keys[key.id] = key
+		if !seenMethods[key.method.Alg()] {
+			seenMethods[key.method.Alg()] = true
+			ring.methods = append(ring.methods, key.method.Alg())
+		}
+	}
+
+	if activeID == "" && len(keyConfigs) == 1 {
+		activeID = keyConfigs[0].ID
+	}
+	active, ok := ring.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active jwt key %q is not configured", activeID)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("active jwt key %q has no private key", activeID)
+	}
+	ring.active = active
+
+	return ring, nil
+}
+
+// loadSigningKey 按算法解析单个密钥
+func loadSigningKey(kc JWTKeyConfig) (*signingKey, error) {
+	if kc.ID == "" {
+		return nil, errors.New("id must not be empty")
+	}
+	key := &signingKey{id: kc.ID}
+
+	switch kc.Algorithm {
+	case AlgHS256:
+		if kc.Secret == "" {
+			return nil, errors.New("secret must not be empty for HS256")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = []byte(kc.Secret)
+		key.verifyKey = []byte(kc.Secret)
+
+	case AlgRS256:
+		key.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			priv, err := readPEM(kc.PrivateKeyFile, func(b []byte) (interface{}, error) {
+				return jwt.ParseRSAPrivateKeyFromPEM(b)
+			})
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = priv
+			key.verifyKey = &priv.(*rsa.PrivateKey).PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			pub, err := readPEM(kc.PublicKeyFile, func(b []byte) (interface{}, error) {
+				return jwt.ParseRSAPublicKeyFromPEM(b)
+			})
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		}
+
+	case AlgEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			priv, err := readPEM(kc.PrivateKeyFile, func(b []byte) (interface{}, error) {
+				return jwt.ParseEdPrivateKeyFromPEM(b)
+			})
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = priv
+			key.verifyKey = priv.(crypto.Signer).Public().(ed25519.PublicKey)
+		}
+		if kc.PublicKeyFile != "" {
+			pub, err := readPEM(kc.PublicKeyFile, func(b []byte) (interface{}, error) {
+				return jwt.ParseEdPublicKeyFromPEM(b)
+			})
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+
+	if key.verifyKey == nil {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+	return key, nil
+}
+
+// readPEM 读取PEM文件并交给对应的解析函数
+func readPEM(path string, parse func([]byte) (interface{}, error)) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// Sign 使用当前密钥签名，并在头部写入 kid
+func (k *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.method, claims)
+	token.Header["kid"] = k.active.id
+	return token.SignedString(k.active.signKey)
+}
+
+// Keyfunc 根据令牌头部的 kid 选择验签密钥，并确认算法与密钥一致
+func (k *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing kid header")
+	}
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// Methods 返回已配置密钥使用的全部算法，用于限制可接受的 alg
+func (k *KeyRing) Methods() []string {
+	return k.methods
+}
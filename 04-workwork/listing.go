@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxPageSize 列表接口单页最多返回的条数
+	maxPageSize = 100
+)
+
+// listSpec 描述一个列表接口支持的查询参数
+type listSpec struct {
+	DefaultLimit int
+	Table        string            // 过滤条件使用的表名
+	SortFields   map[string]string // sort 参数值 -> ORDER BY 表达式，为空表示不支持排序
+	DefaultSort  string
+	Fields       map[string]string // fields 参数值 -> JSON字段名，为空表示不支持投影
+	Cursor       bool              // 是否支持游标分页（按 created_at + id）
+	Topics       bool              // 是否支持 tag、category 过滤（只用于文章）
+}
+
+// postListSpec 文章列表支持的参数
+var postListSpec = &listSpec{
+	DefaultLimit: 10,
+	Table:        "posts",
+	SortFields: map[string]string{
+		"created_at": "posts.created_at",
+		"updated_at": "posts.updated_at",
+		"title":      "posts.title",
+		"comments":   "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.status = 'approved' AND comments.deleted_at IS NULL)",
+	},
+	DefaultSort: "created_at",
+	Fields: map[string]string{
+		"id":            "id",
+		"created_at":    "created_at",
+		"updated_at":    "updated_at",
+		"title":         "title",
+		"slug":          "slug",
+		"content":       "content",
+		"content_html":  "content_html",
+		"user_id":       "user_id",
+		"status":        "status",
+		"publish_at":    "publish_at",
+		"version":       "version",
+		"tags":          "tags",
+		"categories":    "categories",
+		"author":        "author",
+		"comment_count": "comment_count",
+	},
+	Cursor: true,
+	Topics: true,
+}
+
+// commentListSpec 评论列表支持的参数
+var commentListSpec = &listSpec{
+	DefaultLimit: 20,
+	Table:        "comments",
+	SortFields: map[string]string{
+		"created_at": "comments.created_at",
+		"updated_at": "comments.updated_at",
+	},
+	DefaultSort: "created_at",
+	Fields: map[string]string{
+		"id":           "id",
+		"created_at":   "created_at",
+		"updated_at":   "updated_at",
+		"content":      "content",
+		"content_html": "content_html",
+		"status":       "status",
+		"post_id":      "post_id",
+		"parent_id":    "parent_id",
+		"user_id":      "user_id",
+		"author":       "author",
+	},
+	Cursor: true,
+}
+
+// commentTreeSpec 树形评论支持的参数（顶层评论排序，不支持字段投影）
+var commentTreeSpec = &listSpec{
+	DefaultLimit: 20,
+	Table:        "comments",
+	SortFields:   commentListSpec.SortFields,
+	DefaultSort:  "created_at",
+}
+
+// searchListSpec 全文检索支持的参数（按相关度排序）
+var searchListSpec = &listSpec{
+	DefaultLimit: 10,
+	Table:        "posts",
+	Topics:       true,
+}
+
+// adminListSpec 管理列表支持的参数（只分页）
+var adminListSpec = &listSpec{
+	DefaultLimit: 20,
+}
+
+// listParams 校验后的列表查询参数
+type listParams struct {
+	Page     int
+	Limit    int
+	Author   string
+	Tag      string // 规范化后的标签名称
+	Category string // 分类ID或名称
+	Since    *time.Time
+	Until    *time.Time
+	Sort     string
+	Desc     bool
+	Fields   []string    // 需要返回的字段（参数值），为空表示全部
+	Cursor   *pageCursor // 游标分页时非空，此时忽略 page
+
+	// Statuses 只返回这些状态的文章，由处理函数设置而不是从查询参数解析
+	Statuses []string
+
+	spec    *listSpec
+	cursors *cursorCodec
+}
+
+// parseListParams 解析并校验 page、limit、author、since、until、sort、order、fields、cursor
+func parseListParams(c *gin.Context, spec *listSpec, cursors *cursorCodec) (*listParams, error) {
+	p := &listParams{Page: 1, Limit: spec.DefaultLimit, Sort: spec.DefaultSort, Desc: true, spec: spec, cursors: cursors}
+
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return nil, errValidation(FieldError{Field: "page", Code: "gte", Param: "1"})
+		}
+		p.Page = page
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return nil, errValidation(FieldError{Field: "limit", Code: "range", Param: "1," + strconv.Itoa(maxPageSize)})
+		}
+		p.Limit = limit
+	}
+
+	p.Author = strings.TrimSpace(c.Query("author"))
+	if spec.Topics {
+		p.Tag = normalizeTag(c.Query("tag"))
+		p.Category = strings.TrimSpace(c.Query("category"))
+	} else {
+		for _, field := range []string{"tag", "category"} {
+			if c.Query(field) != "" {
+				return nil, errValidation(FieldError{Field: field, Code: "unsupported"})
+			}
+		}
+	}
+
+	if v := c.Query("since"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			return nil, errValidation(FieldError{Field: "since", Code: "datetime"})
+		}
+		p.Since = &t
+	}
+	if v := c.Query("until"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			return nil, errValidation(FieldError{Field: "until", Code: "datetime"})
+		}
+		p.Until = &t
+	}
+	if p.Since != nil && p.Until != nil && p.Since.After(*p.Until) {
+		return nil, errValidation(FieldError{Field: "since", Code: "not_after", Param: "until"})
+	}
+
+	if v := c.Query("sort"); v != "" {
+		if _, ok := spec.SortFields[v]; !ok {
+			return nil, errValidation(FieldError{Field: "sort", Code: "oneof", Param: joinKeys(spec.SortFields)})
+		}
+		p.Sort = v
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+	case "asc":
+		p.Desc = false
+	default:
+		return nil, errValidation(FieldError{Field: "order", Code: "oneof", Param: "asc desc"})
+	}
+
+	if v := c.Query("fields"); v != "" {
+		if len(spec.Fields) == 0 {
+			return nil, errValidation(FieldError{Field: "fields", Code: "unsupported"})
+		}
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			if _, ok := spec.Fields[f]; !ok {
+				return nil, errValidation(FieldError{Field: "fields", Code: "oneof", Param: joinKeys(spec.Fields)})
+			}
+			p.Fields = append(p.Fields, f)
+		}
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		if !spec.Cursor || cursors == nil {
+			return nil, errValidation(FieldError{Field: "cursor", Code: "unsupported"})
+		}
+		if c.Query("page") != "" {
+			return nil, errValidation(FieldError{Field: "cursor", Code: "conflict", Param: "page"})
+		}
+		cur, err := cursors.Decode(v)
+		if err != nil || cur.Scope != spec.Table {
+			return nil, errValidation(FieldError{Field: "cursor", Code: "invalid"})
+		}
+		// 游标固定按创建时间排序，排序方向沿用生成游标时的方向
+		if p.Sort != "created_at" {
+			return nil, errValidation(FieldError{Field: "sort", Code: "oneof", Param: "created_at"})
+		}
+		if c.Query("order") != "" && p.Desc != cur.Desc {
+			return nil, errValidation(FieldError{Field: "order", Code: "cursor_mismatch"})
+		}
+		p.Desc = cur.Desc
+		p.Cursor = cur
+	}
+
+	return p, nil
+}
+
+// Offset 当前页的偏移量
+func (p *listParams) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// applyFilters 应用作者、标签、分类、时间范围和状态过滤；author 和 category 可以是ID或名称
+func (p *listParams) applyFilters(db *gorm.DB) *gorm.DB {
+	table := p.spec.Table
+	if len(p.Statuses) > 0 {
+		db = db.Where(table+".status IN ?", p.Statuses)
+	}
+	if p.Tag != "" {
+		db = db.Where(table+".id IN (?)", db.Session(&gorm.Session{NewDB: true}).Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name = ?", p.Tag))
+	}
+	if p.Category != "" {
+		sub := db.Session(&gorm.Session{NewDB: true}).Table("post_categories").Select("post_categories.post_id")
+		if categoryID, err := strconv.ParseUint(p.Category, 10, 32); err == nil {
+			sub = sub.Where("post_categories.category_id = ?", categoryID)
+		} else {
+			sub = sub.Joins("JOIN categories ON categories.id = post_categories.category_id").Where("categories.name = ?", p.Category)
+		}
+		db = db.Where(table+".id IN (?)", sub)
+	}
+	if p.Author != "" {
+		if authorID, err := strconv.ParseUint(p.Author, 10, 32); err == nil {
+			db = db.Where(table+".user_id = ?", authorID)
+		} else {
+			db = db.Where(table+".user_id IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&User{}).Select("id").Where("username = ?", p.Author))
+		}
+	}
+	if p.Since != nil {
+		db = db.Where(table+".created_at >= ?", p.Since.UTC())
+	}
+	if p.Until != nil {
+		db = db.Where(table+".created_at <= ?", p.Until.UTC())
+	}
+	return db
+}
+
+// applyOrder 按排序参数排序，使用主键作为次要排序保证结果稳定
+func (p *listParams) applyOrder(db *gorm.DB) *gorm.DB {
+	return p.orderBy(db, p.Desc)
+}
+
+func (p *listParams) orderBy(db *gorm.DB, desc bool) *gorm.DB {
+	dir := " desc"
+	if !desc {
+		dir = " asc"
+	}
+	return db.Order(p.spec.SortFields[p.Sort] + dir).Order(p.spec.Table + ".id" + dir)
+}
+
+// applyPage 应用排序和分页：有游标时按 (created_at, id) 定位，否则按 page 偏移
+// 多取一行用于判断是否还有下一页，结果需交给 finishPage 处理
+func (p *listParams) applyPage(db *gorm.DB) *gorm.DB {
+	if p.Cursor == nil {
+		return p.applyOrder(db).Offset(p.Offset()).Limit(p.Limit + 1)
+	}
+
+	// 向前翻页时反向查询离游标最近的一页，finishPage 再恢复原顺序
+	desc := p.Desc != p.Cursor.Before
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	t := p.spec.Table
+	db = db.Where(
+		fmt.Sprintf("(%[1]s.created_at %[2]s ? OR (%[1]s.created_at = ? AND %[1]s.id %[2]s ?))", t, op),
+		p.Cursor.CreatedAt, p.Cursor.CreatedAt, p.Cursor.ID,
+	)
+	return p.orderBy(db, desc).Limit(p.Limit + 1)
+}
+
+// cursorKey 游标定位一行所需的排序键
+type cursorKey struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// cursorItem 支持游标分页的列表项
+type cursorItem interface {
+	cursorKey() cursorKey
+}
+
+func (p Post) cursorKey() cursorKey    { return cursorKey{p.CreatedAt, p.ID} }
+func (c Comment) cursorKey() cursorKey { return cursorKey{c.CreatedAt, c.ID} }
+
+// finishPage 去掉 applyPage 多取的一行、恢复反向查询的顺序，并生成分页信息
+// 按创建时间排序时返回 next_cursor/prev_cursor，没有更多数据时为 null
+func finishPage[T cursorItem](p *listParams, items []T, total int64) ([]T, gin.H) {
+	hasMore := len(items) > p.Limit
+	if hasMore {
+		items = items[:p.Limit]
+	}
+	if p.Cursor != nil && p.Cursor.Before {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	pagination := gin.H{
+		"limit": p.Limit,
+		"total": total,
+	}
+	if p.Cursor == nil {
+		pagination["page"] = p.Page
+	}
+	if !p.spec.Cursor || p.Sort != "created_at" || p.cursors == nil {
+		return items, pagination
+	}
+
+	var hasNext, hasPrev bool
+	switch {
+	case p.Cursor == nil:
+		hasNext, hasPrev = hasMore, p.Page > 1
+	case p.Cursor.Before:
+		hasNext, hasPrev = true, hasMore
+	default:
+		hasNext, hasPrev = hasMore, true
+	}
+
+	var next, prev interface{}
+	if len(items) > 0 {
+		if hasNext {
+			next = p.encodeCursor(items[len(items)-1].cursorKey(), false)
+		}
+		if hasPrev {
+			prev = p.encodeCursor(items[0].cursorKey(), true)
+		}
+	}
+	pagination["next_cursor"] = next
+	pagination["prev_cursor"] = prev
+	return items, pagination
+}
+
+func (p *listParams) encodeCursor(key cursorKey, before bool) string {
+	return p.cursors.Encode(pageCursor{
+		Scope:     p.spec.Table,
+		CreatedAt: key.CreatedAt,
+		ID:        key.ID,
+		Desc:      p.Desc,
+		Before:    before,
+	})
+}
+
+// wants 是否需要返回某个字段（未指定 fields 时返回全部）
+func (p *listParams) wants(field string) bool {
+	if len(p.Fields) == 0 {
+		return true
+	}
+	for _, f := range p.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// project 按 fields 参数裁剪列表中每一项的JSON字段
+func (p *listParams) project(items interface{}) (interface{}, error) {
+	if len(p.Fields) == 0 {
+		return items, nil
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	projected := make([]map[string]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		out := make(map[string]json.RawMessage, len(p.Fields))
+		for _, f := range p.Fields {
+			key := p.spec.Fields[f]
+			if v, ok := row[key]; ok {
+				out[key] = v
+			}
+		}
+		projected = append(projected, out)
+	}
+	return projected, nil
+}
+
+// joinKeys 列出允许的参数值（空格分隔，与 oneof 规则一致），用于错误提示
+func joinKeys(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
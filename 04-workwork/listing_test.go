@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSinceUntilInNonUTCZone since/until 可以使用任意时区，与服务器所在时区无关
+func TestSinceUntilInNonUTCZone(t *testing.T) {
+	withLocalZone(t, -5*3600)
+	s, _ := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Now", "content": "content"})
+
+	total := func(query string) int {
+		t.Helper()
+		rec := alice.mustDo(http.StatusOK, http.MethodGet, "/api/posts?"+query, nil)
+		var resp struct {
+			Data struct {
+				Pagination struct {
+					Total int `json:"total"`
+				} `json:"pagination"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data.Pagination.Total
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	for _, zone := range []*time.Location{time.UTC, time.FixedZone("E", 9*3600), time.Local} {
+		at := url.QueryEscape(hourAgo.In(zone).Format(time.RFC3339))
+		if got := total("since=" + at); got != 1 {
+			t.Fatalf("since %s: total = %d, want 1", at, got)
+		}
+		if got := total("until=" + at); got != 0 {
+			t.Fatalf("until %s: total = %d, want 0", at, got)
+		}
+	}
+}
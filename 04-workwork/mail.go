@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 邮件发送方式
+const (
+	MailBackendSMTP   = "smtp"   // 通过SMTP服务器发送
+	MailBackendFile   = "file"   // 写入目录中的 .eml 文件，便于开发时查看
+	MailBackendMemory = "memory" // 保存在内存中，用于测试和本地开发
+)
+
+// Mail 一封纯文本邮件
+type Mail struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg Mail) error
+}
+
+// newMailer 根据配置创建邮件发送器
+func newMailer(cfg MailConfig) (Mailer, error) {
+	switch cfg.Backend {
+	case MailBackendSMTP:
+		return &smtpMailer{cfg: cfg.SMTP}, nil
+	case MailBackendFile:
+		if err := os.MkdirAll(cfg.OutboxDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create mail outbox dir: %w", err)
+		}
+		return &fileOutbox{dir: cfg.OutboxDir}, nil
+	case MailBackendMemory:
+		return &memoryOutbox{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported mail backend %q", cfg.Backend)
+	}
+}
+
+// Bytes 按 RFC 5322 生成邮件原文，正文使用 quoted-printable 编码
+func (m Mail) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender: %w", err)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return nil, errors.New("mail subject must not contain line breaks")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// smtpMailer 通过SMTP发送，服务器支持时自动使用STARTTLS
+type smtpMailer struct {
+	cfg SMTPConfig
+}
+
+// Send 发送邮件
+func (m *smtpMailer) Send(msg Mail) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	// Bytes 已校验过地址，这里取出信封使用的纯邮箱地址
+	from, _ := mail.ParseAddress(msg.From)
+	to, _ := mail.ParseAddress(msg.To)
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	return smtp.SendMail(addr, auth, from.Address, []string{to.Address}, data)
+}
+
+// fileOutbox 每封邮件写成一个 .eml 文件
+type fileOutbox struct {
+	dir string
+	seq atomic.Uint64
+}
+
+// Send 写入邮件文件
+func (o *fileOutbox) Send(msg Mail) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), o.seq.Add(1))
+	return os.WriteFile(filepath.Join(o.dir, name), data, 0o600)
+}
+
+// memoryOutbox 把邮件保存在内存中，测试时可以直接断言发出的内容
+type memoryOutbox struct {
+	mu       sync.Mutex
+	messages []Mail
+}
+
+// Send 保存邮件
+func (o *memoryOutbox) Send(msg Mail) error {
+	if _, err := msg.Bytes(); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages 返回已发送邮件的副本，按发送顺序排列
+func (o *memoryOutbox) Messages() []Mail {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Mail(nil), o.messages...)
+}
+
+// Last 返回最近一封发给指定地址的邮件
+func (o *memoryOutbox) Last(to string) (Mail, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if strings.EqualFold(o.messages[i].To, to) {
+			return o.messages[i], true
+		}
+	}
+	return Mail{}, false
+}
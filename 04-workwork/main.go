@@ -1,67 +1,56 @@
 package main
 
 import (
-	"github.com/gin-gonic/gin"
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
+	"context"
+	"flag"
 	"log"
-)
+	"os"
 
-var db *gorm.DB
+	"github.com/gin-gonic/gin"
+)
 
 func main() {
-	// 连接数据库
-	var err error
+	// 加载配置（配置文件 < 环境变量 < 命令行参数）
+	cfg, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		log.Fatal("Invalid configuration: ", err)
+	}
+	if cfg.Mode == ModeProd {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
 	// GORM数据库连接
-	db, err = gorm.Open(mysql.Open("root:root@tcp(127.0.0.1:3306)/gorm?charset=utf8mb4&parseTime=True&loc=Local"))
+	db, err := openDatabase(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
 	// 自动迁移模型
-	err = db.AutoMigrate(&User{}, &Post{}, &Comment{})
+	err = migrateDatabase(db)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
-	// 创建Gin路由
-	r := gin.Default()
-
-	// 添加中间件
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
-
-	// 设置路由组
-	api := r.Group("/api")
-	{
-		// 用户认证相关路由
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", Register)
-			auth.POST("/login", Login)
-		}
-
-		// 文章相关路由
-		posts := api.Group("/posts")
-		{
-			posts.GET("", GetPosts)                            // 获取所有文章
-			posts.GET("/:id", GetPost)                         // 获取单个文章
-			posts.POST("", AuthMiddleware(), CreatePost)       // 创建文章
-			posts.PUT("/:id", AuthMiddleware(), UpdatePost)    // 更新文章
-			posts.DELETE("/:id", AuthMiddleware(), DeletePost) // 删除文章
-		}
+	// 创建服务实例（包含Gin路由）
+	server, err := NewServer(cfg, db)
+	if err != nil {
+		log.Fatal("Failed to create server: ", err)
+	}
 
-		// 评论相关路由
-		comments := api.Group("/comments")
-		{
-			comments.GET("/post/:postId", GetComments)         // 获取文章评论
-			comments.POST("", AuthMiddleware(), CreateComment) // 创建评论
-		}
+	// 授予配置中的管理员角色
+	if err := server.ensureAdmins(); err != nil {
+		log.Fatal("Failed to grant admin role:", err)
 	}
 
+	// 后台定时发布文章
+	go server.RunPublisher(context.Background())
+
 	// 启动服务器
-	log.Println("Server starting on :8080")
-	if err := r.Run(":8080"); err != nil {
+	log.Println("Server starting on", cfg.Addr)
+	if err := server.Run(cfg.Addr); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
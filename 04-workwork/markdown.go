@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"gorm.io/gorm"
+)
+
+// markdownRenderer Markdown 渲染器，支持 GitHub 风格的表格、删除线、自动链接和任务列表
+// 允许 Markdown 中夹带 HTML，渲染结果统一交给 htmlSanitizer 过滤
+var markdownRenderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+)
+
+// htmlSanitizer 白名单过滤：只保留常见的排版标签和安全的链接、图片地址，
+// 去掉脚本、事件属性、style 和 javascript: 等链接；创建后可以并发使用
+var htmlSanitizer = newHTMLSanitizer()
+
+// newHTMLSanitizer 在 UGC 策略的基础上允许任务列表的只读复选框
+func newHTMLSanitizer() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// renderMarkdown 把用户提交的 Markdown 渲染为可以直接插入页面的 HTML
+func renderMarkdown(source string) string {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(source), &buf); err != nil {
+		// 渲染失败时退回为转义后的纯文本
+		return "<p>" + html.EscapeString(source) + "</p>"
+	}
+	return htmlSanitizer.Sanitize(buf.String())
+}
+
+// backfillContentHTML 为增加渲染缓存之前的文章和评论（包括回收站中的）生成 content_html
+func backfillContentHTML(db *gorm.DB) error {
+	unscoped := db.Unscoped().Session(&gorm.Session{})
+
+	var posts []Post
+	if err := unscoped.Select("id", "content").Where("content_html IS NULL OR content_html = ''").
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				if err := unscoped.Model(&post).UpdateColumn("content_html", renderMarkdown(post.Content)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error; err != nil {
+		return err
+	}
+
+	var comments []Comment
+	return unscoped.Select("id", "content").Where("content_html IS NULL OR content_html = ''").
+		FindInBatches(&comments, 100, func(tx *gorm.DB, batch int) error {
+			for _, comment := range comments {
+				if err := unscoped.Model(&comment).UpdateColumn("content_html", renderMarkdown(comment.Content)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -1,37 +1,152 @@
 package main
 
 import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	RoleUser      = "user"      // 普通用户
+	RoleModerator = "moderator" // 版主：管理评论
+	RoleAdmin     = "admin"     // 管理员：拥有全部权限
+)
+
 // User 用户模型
 type User struct {
 	gorm.Model
-	Username string `gorm:"unique;not null" json:"username"`
-	Password string `gorm:"not null" json:"-"` // 密码不返回给前端
-	Email    string `gorm:"unique;not null" json:"email"`
-	Posts    []Post `json:"posts,omitempty"`
-	Comments []Comment `json:"comments,omitempty"`
+	Username        string     `gorm:"unique;not null" json:"username"`
+	Password        string     `gorm:"not null" json:"-"` // 密码不返回给前端
+	Email           string     `gorm:"unique;not null" json:"email"`
+	Role            string     `gorm:"size:20;not null;default:user" json:"role"`
+	Nickname        string     `gorm:"size:50" json:"nickname"`
+	Avatar          string     `gorm:"size:255" json:"avatar"` // 头像URL
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`      // 邮箱验证时间，未验证或修改邮箱后为空
+	Posts           []Post     `json:"posts,omitempty"`
+	Comments        []Comment  `json:"comments,omitempty"`
 }
 
+// 文章状态，只有 published 对所有人可见，其余状态只有作者本人可见
+const (
+	PostStatusDraft     = "draft"     // 草稿
+	PostStatusScheduled = "scheduled" // 定时发布，到达 publish_at 后由后台任务改为 published
+	PostStatusPublished = "published" // 已发布
+	PostStatusArchived  = "archived"  // 已归档，不再公开
+)
+
+// postStatuses 全部文章状态
+var postStatuses = []string{PostStatusDraft, PostStatusScheduled, PostStatusPublished, PostStatusArchived}
+
 // Post 文章模型
 type Post struct {
 	gorm.Model
-	Title   string `gorm:"not null" json:"title"`
-	Content string `gorm:"not null" json:"content"`
-	UserID  uint   `json:"user_id"`
-	User    User   `json:"user,omitempty"`
-	Comments []Comment `json:"comments,omitempty"`
+	Title       string     `gorm:"not null" json:"title"`
+	Slug        string     `gorm:"size:100;index" json:"slug"` // 当前的 slug，由标题生成，唯一性由 PostSlug 保证
+	Content     string     `gorm:"not null" json:"content"`
+	ContentHTML string     `json:"content_html"` // content 渲染并过滤后的 HTML，修改 content 时重新生成
+	UserID      uint       `json:"user_id"`
+	Status      string     `gorm:"size:20;not null;default:published;index" json:"status"`
+	PublishAt   *time.Time `gorm:"index" json:"publish_at"`                  // 发布时间；定时发布时为计划时间，草稿为空
+	TakenDown   bool       `gorm:"not null;default:false" json:"taken_down"` // 被管理员下架，作者不能从回收站恢复
+	Version     int        `gorm:"not null;default:1" json:"version"`        // 每次修改加1，用作 ETag
+	User        User       `json:"user,omitempty"`
+	Comments    []Comment  `json:"comments,omitempty"`
+
+	Tags       []Tag      `gorm:"many2many:post_tags" json:"tags,omitempty"`
+	Categories []Category `gorm:"many2many:post_categories" json:"categories,omitempty"`
 }
 
+// PostSlug 文章用过的全部 slug（包括当前的），保证 slug 不会被其他文章占用，旧链接可以跳转到新地址
+type PostSlug struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	PostID    uint   `gorm:"index;not null"`
+	Slug      string `gorm:"size:100;uniqueIndex;not null"`
+}
+
+// PostRevision 文章的历史版本，创建和每次修改文章后记录一份完整快照
+type PostRevision struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	PostID       uint `gorm:"uniqueIndex:idx_post_revision;not null"`
+	Number       int  `gorm:"uniqueIndex:idx_post_revision;not null"` // 文章内的版本号，从1开始
+	EditorID     uint `gorm:"not null"`
+	Editor       User
+	Title        string   `gorm:"not null"`
+	Content      string   `gorm:"not null"`
+	Tags         []string `gorm:"serializer:json"`
+	Categories   []string `gorm:"serializer:json"`
+	RestoredFrom *int     // 由恢复操作产生时为来源的版本号
+}
+
+// Tag 标签，作者发布文章时自由填写，首次使用时自动创建
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `gorm:"size:50;uniqueIndex;not null" json:"name"` // 规范化后的名称（小写、去掉多余空白）
+}
+
+// Category 分类，由管理员维护，文章只能选择已有的分类
+type Category struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Name        string    `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+}
+
+// visibleTo 文章对该用户是否可见：已发布的文章所有人可见，其余只有作者可见
+func (p Post) visibleTo(userID uint) bool {
+	return p.Status == PostStatusPublished || (userID != 0 && p.UserID == userID)
+}
+
+// 评论审核状态
+const (
+	CommentStatusApproved = "approved" // 已通过，公开可见
+	CommentStatusPending  = "pending"  // 待审核
+	CommentStatusRejected = "rejected" // 已拒绝
+)
+
 // Comment 评论模型
 type Comment struct {
 	gorm.Model
-	Content string `gorm:"not null" json:"content"`
-	UserID  uint   `json:"user_id"`
-	User    User   `json:"user,omitempty"`
-	PostID  uint   `json:"post_id"`
-	Post    Post   `json:"post,omitempty"`
+	Content     string `gorm:"not null" json:"content"`
+	ContentHTML string `json:"content_html"`                                          // content 渲染并过滤后的 HTML
+	Status      string `gorm:"size:20;not null;default:approved;index" json:"status"` // approved, pending, rejected
+	UserID      uint   `json:"user_id"`
+	User        User   `json:"user,omitempty"`
+	PostID      uint   `json:"post_id"`
+	Post        Post   `json:"post,omitempty"`
+	ParentID    *uint  `gorm:"index;default:null" json:"parent_id"` // 父评论ID，支持回复功能
+}
+
+// RefreshToken 刷新令牌（只保存哈希），每次刷新都会轮换
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	FamilyID  string     `gorm:"size:32;index;not null" json:"-"` // 同一次登录轮换出的令牌属于同一家族
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// RevokedToken 已吊销的访问令牌（按jti记录），过期后即可清理
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:32" json:"jti"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLog 安全审计记录（如登录锁定），只追加不修改
+type AuditLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	Action    string    `gorm:"size:50;index;not null" json:"action"`
+	UserID    *uint     `gorm:"index" json:"user_id"` // 关联的用户，用户不存在时为空
+	Username  string    `gorm:"size:100" json:"username"`
+	IP        string    `gorm:"size:64" json:"ip"`
+	Detail    string    `gorm:"size:255" json:"detail"`
 }
 
 // LoginRequest 登录请求结构
@@ -42,40 +157,121 @@ type LoginRequest struct {
 
 // RegisterRequest 注册请求结构
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,username"`
 	Password string `json:"password" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 }
 
+// RefreshTokenRequest 刷新令牌请求结构
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 注销请求结构（请求体可省略）
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // 同时吊销该刷新令牌
+	All          bool   `json:"all"`           // 吊销当前用户的全部刷新令牌
+}
+
 // CreatePostRequest 创建文章请求结构
+// 不指定 status 时立即发布；只提供 publish_at 时视为定时发布
 type CreatePostRequest struct {
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	Title      string     `json:"title" binding:"required"`
+	Content    string     `json:"content" binding:"required"`
+	Status     string     `json:"status" binding:"omitempty,oneof=draft scheduled published"`
+	PublishAt  *time.Time `json:"publish_at"` // 定时发布的时间，必须晚于当前时间
+	Tags       []string   `json:"tags"`       // 标签名称，不存在时自动创建
+	Categories []string   `json:"categories"` // 分类名称，必须是已有的分类
 }
 
 // UpdatePostRequest 更新文章请求结构
 type UpdatePostRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Status     string     `json:"status" binding:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt  *time.Time `json:"publish_at"` // 修改定时发布的时间
+	Tags       *[]string  `json:"tags"`       // 提供时整体替换，空数组表示清除
+	Categories *[]string  `json:"categories"` // 提供时整体替换，空数组表示清除
+}
+
+// CreateCategoryRequest 创建分类请求
+type CreateCategoryRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Description string `json:"description" binding:"max=255"`
 }
 
 // CreateCommentRequest 创建评论请求结构
 type CreateCommentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	PostID   uint   `json:"post_id" binding:"required"`
+	ParentID *uint  `json:"parent_id"` // 回复的评论ID，必须属于同一篇文章
+}
+
+// UpdateCommentRequest 编辑评论请求结构
+type UpdateCommentRequest struct {
 	Content string `json:"content" binding:"required"`
-	PostID  uint   `json:"post_id" binding:"required"`
 }
 
-// JWTClaims JWT声明结构
+// UpdateCommentStatusRequest 审核评论请求结构
+type UpdateCommentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved pending rejected"`
+}
+
+// UpdateProfileRequest 修改个人资料请求，未提供的字段保持不变
+// 修改邮箱需要同时提供当前密码
+type UpdateProfileRequest struct {
+	Nickname        *string `json:"nickname" binding:"omitempty,max=50"`
+	Avatar          *string `json:"avatar" binding:"omitempty,max=255"` // 空字符串表示清除头像
+	Email           *string `json:"email" binding:"omitempty,email"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// DeleteAccountRequest 注销账户请求
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// VerifyEmailRequest 验证邮箱请求
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PasswordResetRequest 申请重置密码请求
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// PasswordResetConfirmRequest 使用邮件中的令牌设置新密码
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// UpdateUserRoleRequest 修改用户角色请求结构
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+// JWTClaims JWT声明结构（iss/aud/exp/nbf/iat/jti 使用标准声明）
 type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
-	Exp      int64  `json:"exp"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
 }
 
 // APIResponse API响应结构
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Data    interface{}  `json:"data,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Code    string       `json:"code,omitempty"`    // 错误码，见 errors.go
+	Details []FieldError `json:"details,omitempty"` // 逐字段的校验错误
 }
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes bcrypt 只使用前72个字节，更长的密码会被拒绝
+const maxPasswordBytes = 72
+
+// passwordPolicy 密码强度校验
+type passwordPolicy struct {
+	minLength  int
+	minClasses int
+	cost       int
+	breached   map[[sha1.Size]byte]struct{} // 泄露密码的SHA-1
+}
+
+// newPasswordPolicy 根据配置创建密码策略，配置了泄露密码列表时一并加载
+func newPasswordPolicy(cfg AuthConfig) (*passwordPolicy, error) {
+	p := &passwordPolicy{
+		minLength:  cfg.Password.MinLength,
+		minClasses: cfg.Password.MinClasses,
+		cost:       cfg.BcryptCost,
+	}
+	if cfg.Password.BreachedList != "" {
+		breached, err := loadBreachedPasswords(cfg.Password.BreachedList)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = breached
+	}
+	return p, nil
+}
+
+// loadBreachedPasswords 读取泄露密码列表
+// 每行一个明文密码，或一个SHA-1十六进制摘要（兼容 "摘要:次数" 的格式）；空行和 # 开头的行忽略
+func loadBreachedPasswords(path string) (map[[sha1.Size]byte]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	breached := make(map[[sha1.Size]byte]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if sum, ok := parseSHA1Line(line); ok {
+			breached[sum] = struct{}{}
+			continue
+		}
+		breached[sha1.Sum([]byte(line))] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+	return breached, nil
+}
+
+// parseSHA1Line 解析 "摘要" 或 "摘要:次数" 形式的行
+func parseSHA1Line(line string) ([sha1.Size]byte, bool) {
+	var sum [sha1.Size]byte
+	digest, count, hasCount := strings.Cut(line, ":")
+	if len(digest) != hex.EncodedLen(sha1.Size) {
+		return sum, false
+	}
+	if hasCount {
+		if _, err := strconv.Atoi(count); err != nil {
+			return sum, false
+		}
+	}
+	if _, err := hex.Decode(sum[:], []byte(digest)); err != nil {
+		return sum, false
+	}
+	return sum, true
+}
+
+// Check 校验密码，返回所有不满足的规则；field 为请求中的字段名
+func (p *passwordPolicy) Check(field, password string) []FieldError {
+	var fields []FieldError
+	if utf8.RuneCountInString(password) < p.minLength {
+		fields = append(fields, FieldError{Field: field, Code: "min", Param: strconv.Itoa(p.minLength)})
+	}
+	if len(password) > maxPasswordBytes {
+		fields = append(fields, FieldError{Field: field, Code: "max_bytes", Param: strconv.Itoa(maxPasswordBytes)})
+	}
+	if classes := passwordClasses(password); classes < p.minClasses {
+		fields = append(fields, FieldError{Field: field, Code: "password_classes", Param: strconv.Itoa(p.minClasses)})
+	}
+	if _, ok := p.breached[sha1.Sum([]byte(password))]; ok {
+		fields = append(fields, FieldError{Field: field, Code: "password_breached"})
+	}
+	return fields
+}
+
+// Hash 使用配置的代价计算bcrypt哈希
+func (p *passwordPolicy) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), p.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// passwordClasses 统计密码包含的字符种类：小写字母、大写字母、数字、其他字符（符号、汉字等）
+func passwordClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	count := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,162 +9,249 @@ import (
 	"gorm.io/gorm"
 )
 
-// GetPosts 获取所有文章列表
-func GetPosts(c *gin.Context) {
-	var posts []Post
-	
-	// 预加载用户信息和评论数量
-	query := db.Preload("User").Preload("Comments")
-	
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
-	
-	// 执行查询
-	if err := query.Offset(offset).Limit(limit).Order("created_at desc").Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to fetch posts",
+// GetPosts 获取所有已发布的文章列表
+// 支持 author、since、until 过滤，sort=created_at|updated_at|comments|title 配合 order=asc|desc 排序，
+// 以及 fields= 选择返回字段；按创建时间排序时可用 cursor= 进行游标分页
+func (s *Server) GetPosts(c *gin.Context) {
+	params, err := parseListParams(c, postListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	params.Statuses = []string{PostStatusPublished}
+
+	s.listPosts(c, params)
+}
+
+// listPosts 按列表参数查询文章并返回，GetPosts 和用户文章列表共用
+func (s *Server) listPosts(c *gin.Context, params *listParams) {
+	var (
+		posts []Post
+		err   error
+	)
+
+	// 列表不加载评论；作者只查询摘要需要的字段
+	query := params.applyPage(params.applyFilters(s.db.Model(&Post{})))
+	if params.wants("author") {
+		query = query.Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "username", "nickname", "avatar")
 		})
+	}
+	if params.wants("tags") {
+		query = query.Preload("Tags")
+	}
+	if params.wants("categories") {
+		query = query.Preload("Categories")
+	}
+
+	// 执行查询
+	if err := query.Find(&posts).Error; err != nil {
+		c.Error(errInternal("Failed to fetch posts", err))
 		return
 	}
-	
+
 	// 获取总数
 	var total int64
-	db.Model(&Post{}).Count(&total)
-	
+	params.applyFilters(s.db.Model(&Post{})).Count(&total)
+
+	posts, pagination := finishPage(params, posts, total)
+
+	// 一次分组查询获取本页所有文章的评论数
+	var counts map[uint]int64
+	if params.wants("comment_count") {
+		counts, err = s.approvedCommentCounts(posts)
+		if err != nil {
+			c.Error(errInternal("Failed to fetch posts", err))
+			return
+		}
+	}
+
+	items, err := params.project(newPostListViews(posts, counts))
+	if err != nil {
+		c.Error(errInternal("Failed to fetch posts", err))
+		return
+	}
+
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
 		Message: "Posts retrieved successfully",
 		Data: gin.H{
-			"posts": posts,
-			"pagination": gin.H{
-				"page":  page,
-				"limit": limit,
-				"total": total,
-			},
+			"posts":      items,
+			"pagination": pagination,
 		},
 	})
 }
 
-// GetPost 获取单个文章详情
-func GetPost(c *gin.Context) {
+// approvedCommentCounts 统计一组文章各自的已审核评论数
+func (s *Server) approvedCommentCounts(posts []Post) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(posts))
+	if len(posts) == 0 {
+		return counts, nil
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	var rows []struct {
+		PostID uint
+		Count  int64
+	}
+	err := s.db.Model(&Comment{}).
+		Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ? AND status = ?", ids, CommentStatusApproved).
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetPost 获取单个文章详情，未发布的文章只有作者可以查看
+func (s *Server) GetPost(c *gin.Context) {
 	id := c.Param("id")
 	postID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid post ID",
-		})
+		c.Error(errInvalidPostID)
 		return
 	}
 	
 	var post Post
-	if err := db.Preload("User").Preload("Comments.User").First(&post, postID).Error; err != nil {
+	if err := s.postDetailQuery().First(&post, postID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, APIResponse{
-				Success: false,
-				Error:   "Post not found",
-			})
+			c.Error(errPostNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Error:   "Failed to fetch post",
-			})
+			c.Error(errInternal("Failed to fetch post", err))
 		}
 		return
 	}
+	if !post.visibleTo(getCurrentUserID(c)) {
+		c.Error(errPostNotFound)
+		return
+	}
 	
+	c.Header("ETag", postETag(post))
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
 		Message: "Post retrieved successfully",
-		Data:    post,
+		Data:    newPostView(post),
 	})
 }
 
+// postDetailQuery 文章详情的查询，加载作者、标签、分类和已审核的评论
+func (s *Server) postDetailQuery() *gorm.DB {
+	return s.db.Preload("User").Preload("Tags").Preload("Categories").Preload("Comments", "status = ?", CommentStatusApproved).Preload("Comments.User")
+}
+
 // CreatePost 创建新文章
-func CreatePost(c *gin.Context) {
+func (s *Server) CreatePost(c *gin.Context) {
 	var req CreatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid request data: " + err.Error(),
-		})
+		c.Error(bindError(err))
 		return
 	}
 	
 	userID := getCurrentUserID(c)
 	
 	post := Post{
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  userID,
+		Title:       req.Title,
+		Content:     req.Content,
+		ContentHTML: renderMarkdown(req.Content),
+		UserID:      userID,
+		Status:      PostStatusPublished,
+	}
+	if err := applyPostStatus(&post, req.Status, req.PublishAt, dbNow()); err != nil {
+		c.Error(err)
+		return
 	}
 	
-	if err := db.Create(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to create post",
-		})
+	// 标签和分类
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	post.Categories, err = resolveCategories(s.db, req.Categories)
+	if err != nil {
+		c.Error(topicsError(err, "Failed to create post"))
 		return
 	}
 	
+	err = s.transaction(func(tx *gorm.DB) error {
+		if post.Tags, err = resolveTags(tx, tags); err != nil {
+			return err
+		}
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if err := assignSlug(tx, &post); err != nil {
+			return err
+		}
+		return recordRevision(tx, post.ID, userID, nil)
+	})
+	if err != nil {
+		c.Error(errInternal("Failed to create post", err))
+		return
+	}
+	if post.Status == PostStatusScheduled {
+		s.publisher.Wake()
+	}
+	
 	// 重新查询以获取用户信息
-	db.Preload("User").First(&post, post.ID)
+	s.db.Preload("User").Preload("Tags").Preload("Categories").First(&post, post.ID)
 	
+	c.Header("ETag", postETag(post))
 	c.JSON(http.StatusCreated, APIResponse{
 		Success: true,
 		Message: "Post created successfully",
-		Data:    post,
+		Data:    newPostView(post),
 	})
 }
 
 // UpdatePost 更新文章
-func UpdatePost(c *gin.Context) {
+func (s *Server) UpdatePost(c *gin.Context) {
 	id := c.Param("id")
 	postID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid post ID",
-		})
+		c.Error(errInvalidPostID)
 		return
 	}
 	
 	var req UpdatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid request data: " + err.Error(),
-		})
+		c.Error(bindError(err))
 		return
 	}
 	
 	// 查找文章
 	var post Post
-	if err := db.First(&post, postID).Error; err != nil {
+	if err := s.db.First(&post, postID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, APIResponse{
-				Success: false,
-				Error:   "Post not found",
-			})
+			c.Error(errPostNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Error:   "Failed to fetch post",
-			})
+			c.Error(errInternal("Failed to fetch post", err))
 		}
 		return
 	}
 	
-	// 检查权限：只有作者才能更新文章
+	// 检查权限：只有作者才能更新文章；未发布的文章对其他人视为不存在
 	userID := getCurrentUserID(c)
+	if !post.visibleTo(userID) {
+		c.Error(errPostNotFound)
+		return
+	}
 	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, APIResponse{
-			Success: false,
-			Error:   "You can only update your own posts",
-		})
+		c.Error(errCannotUpdatePost)
+		return
+	}
+	// 乐观并发控制：必须基于最新的版本修改，避免覆盖其他人的修改
+	if err := s.checkPostIfMatch(c, post); err != nil {
+		c.Error(err)
 		return
 	}
 	
@@ -174,71 +262,141 @@ func UpdatePost(c *gin.Context) {
 	}
 	if req.Content != "" {
 		updates["content"] = req.Content
+		updates["content_html"] = renderMarkdown(req.Content)
+	}
+	if req.Status != "" || req.PublishAt != nil {
+		if err := applyPostStatus(&post, req.Status, req.PublishAt, dbNow()); err != nil {
+			c.Error(err)
+			return
+		}
+		updates["status"] = post.Status
+		updates["publish_at"] = post.PublishAt
 	}
 	
-	if err := db.Model(&post).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to update post",
+	// 提供了标签或分类时整体替换
+	var (
+		tags       []string
+		categories []Category
+	)
+	if req.Tags != nil {
+		if tags, err = normalizeTags(*req.Tags); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if req.Categories != nil {
+		if categories, err = resolveCategories(s.db, *req.Categories); err != nil {
+			c.Error(topicsError(err, "Failed to update post"))
+			return
+		}
+	}
+	
+	// 没有提供任何修改时不写入，版本号和 ETag 保持不变
+	if len(updates) > 0 || req.Tags != nil || req.Categories != nil {
+		err = s.transaction(func(tx *gorm.DB) error {
+			// 以读取时的版本号为条件更新，并发的修改只有一个能成功
+			if err := bumpPostVersion(tx, &post, updates); err != nil {
+				return err
+			}
+			// 标题变化时生成新的 slug，旧的 slug 继续跳转到这篇文章
+			if _, ok := updates["title"]; ok {
+				if err := assignSlug(tx, &post); err != nil {
+					return err
+				}
+			}
+			if req.Tags != nil {
+				resolved, err := resolveTags(tx, tags)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&post).Association("Tags").Replace(resolved); err != nil {
+					return err
+				}
+			}
+			if req.Categories != nil {
+				if err := tx.Model(&post).Association("Categories").Replace(categories); err != nil {
+					return err
+				}
+			}
+			// 每次修改后记录新的版本
+			return recordRevision(tx, post.ID, userID, nil)
 		})
+	}
+	if errors.Is(err, errStalePost) {
+		c.Error(s.postModified(c, post.ID))
 		return
 	}
+	if err != nil {
+		c.Error(errInternal("Failed to update post", err))
+		return
+	}
+	if _, ok := updates["status"]; ok {
+		s.publisher.Wake()
+	}
 	
 	// 重新查询以获取完整信息
-	db.Preload("User").First(&post, post.ID)
+	s.db.Preload("User").Preload("Tags").Preload("Categories").First(&post, post.ID)
+	view := newPostView(post)
+	if counts, err := s.approvedCommentCounts([]Post{post}); err == nil {
+		view.CommentCount = counts[post.ID]
+	}
 	
+	c.Header("ETag", postETag(post))
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
 		Message: "Post updated successfully",
-		Data:    post,
+		Data:    view,
 	})
 }
 
 // DeletePost 删除文章
-func DeletePost(c *gin.Context) {
+func (s *Server) DeletePost(c *gin.Context) {
 	id := c.Param("id")
 	postID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Success: false,
-			Error:   "Invalid post ID",
-		})
+		c.Error(errInvalidPostID)
 		return
 	}
 	
 	// 查找文章
 	var post Post
-	if err := db.First(&post, postID).Error; err != nil {
+	if err := s.db.First(&post, postID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, APIResponse{
-				Success: false,
-				Error:   "Post not found",
-			})
+			c.Error(errPostNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Error:   "Failed to fetch post",
-			})
+			c.Error(errInternal("Failed to fetch post", err))
 		}
 		return
 	}
 	
-	// 检查权限：只有作者才能删除文章
+	// 检查权限：只有作者才能删除文章；未发布的文章对其他人视为不存在
 	userID := getCurrentUserID(c)
+	if !post.visibleTo(userID) {
+		c.Error(errPostNotFound)
+		return
+	}
 	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, APIResponse{
-			Success: false,
-			Error:   "You can only delete your own posts",
-		})
+		c.Error(errCannotDeletePost)
+		return
+	}
+	if err := s.checkPostIfMatch(c, post); err != nil {
+		c.Error(err)
 		return
 	}
 	
-	// 删除文章（会级联删除相关评论）
-	if err := db.Delete(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "Failed to delete post",
-		})
+	// 文章和它的评论一起移入回收站，作者可以恢复
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := bumpPostVersion(tx, &post, nil); err != nil {
+			return err
+		}
+		return trashPost(tx, &post, false)
+	})
+	if errors.Is(err, errStalePost) {
+		c.Error(s.postModified(c, post.ID))
+		return
+	}
+	if err != nil {
+		c.Error(errInternal("Failed to delete post", err))
 		return
 	}
 	
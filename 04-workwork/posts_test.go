@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// queryCounter 通过 GORM 回调统计执行的查询语句数
+type queryCounter struct {
+	n atomic.Int64
+}
+
+// registerQueryCounter 在 db 上注册计数回调
+func registerQueryCounter(tb testing.TB, db *gorm.DB) *queryCounter {
+	tb.Helper()
+	counter := &queryCounter{}
+	count := func(*gorm.DB) { counter.n.Add(1) }
+	if err := db.Callback().Query().After("gorm:query").Register("test:count_queries", count); err != nil {
+		tb.Fatal(err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("test:count_queries", count); err != nil {
+		tb.Fatal(err)
+	}
+	if err := db.Callback().Raw().After("gorm:raw").Register("test:count_queries", count); err != nil {
+		tb.Fatal(err)
+	}
+	return counter
+}
+
+// seedListPosts 创建 n 篇已发布的文章，每篇带标签、分类和评论
+func seedListPosts(tb testing.TB, db *gorm.DB, n int) {
+	tb.Helper()
+	author := User{Username: "author", Email: "author@example.com", Password: "x"}
+	if err := db.Create(&author).Error; err != nil {
+		tb.Fatal(err)
+	}
+	category := Category{Name: "tech"}
+	if err := db.Create(&category).Error; err != nil {
+		tb.Fatal(err)
+	}
+	tags := []Tag{{Name: "go"}, {Name: "web"}}
+	if err := db.Create(&tags).Error; err != nil {
+		tb.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		post := Post{
+			Title:      fmt.Sprintf("Post %d", i),
+			Slug:       fmt.Sprintf("post-%d", i),
+			Content:    "content",
+			UserID:     author.ID,
+			Status:     PostStatusPublished,
+			Tags:       tags,
+			Categories: []Category{category},
+		}
+		if err := db.Create(&post).Error; err != nil {
+			tb.Fatal(err)
+		}
+		comments := []Comment{
+			{PostID: post.ID, UserID: author.ID, Content: "a", Status: CommentStatusApproved},
+			{PostID: post.ID, UserID: author.ID, Content: "b", Status: CommentStatusPending},
+		}
+		if err := db.Create(&comments).Error; err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// listPostsPageSizes 检查查询数和计时使用的页大小
+var listPostsPageSizes = []int{1, 10, 50, maxPageSize}
+
+// TestListPostsQueryCount 文章列表每页执行的查询数与页大小无关：
+// 作者、标签、分类的预加载和评论数统计都是按本页文章批量查询，不随文章数增加
+func TestListPostsQueryCount(t *testing.T) {
+	s, db := newTestServer(t)
+	seedListPosts(t, db, maxPageSize)
+	counter := registerQueryCounter(t, db)
+	client := newTestClient(t, s)
+
+	queries := make(map[int]int64, len(listPostsPageSizes))
+	for _, size := range listPostsPageSizes {
+		counter.n.Store(0)
+		client.mustDo(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/posts?limit=%d", size), nil)
+		queries[size] = counter.n.Load()
+	}
+	for _, size := range listPostsPageSizes[1:] {
+		if queries[size] != queries[listPostsPageSizes[0]] {
+			t.Fatalf("queries per page depend on page size: %v", queries)
+		}
+	}
+}
+
+// BenchmarkListPosts 不同页大小下文章列表的耗时
+func BenchmarkListPosts(b *testing.B) {
+	s, db := newTestServer(b)
+	seedListPosts(b, db, maxPageSize)
+	client := newTestClient(b, s)
+
+	for _, size := range listPostsPageSizes {
+		path := fmt.Sprintf("/api/posts?limit=%d", size)
+		b.Run(fmt.Sprintf("limit=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				client.mustDo(http.StatusOK, http.MethodGet, path, nil)
+			}
+		})
+	}
+}
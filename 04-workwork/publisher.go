@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// publisherMaxWait 后台发布任务两次检查之间的最长间隔
+// 本进程内的定时修改会立即唤醒任务；其他实例写入的定时文章最迟在这个间隔后被发现
+const publisherMaxWait = time.Minute
+
+// applyPostStatus 根据请求中的 status 和 publish_at 设置文章的状态和发布时间
+// status 为空时：提供了 publish_at 视为定时发布，否则保持当前状态（新文章的当前状态为 published）
+func applyPostStatus(post *Post, status string, publishAt *time.Time, now time.Time) error {
+	if status == "" {
+		status = post.Status
+		if publishAt != nil {
+			status = PostStatusScheduled
+		}
+	}
+	if publishAt != nil && status != PostStatusScheduled {
+		return errValidation(FieldError{Field: "publish_at", Code: "conflict", Param: "status " + status})
+	}
+
+	switch status {
+	case PostStatusDraft:
+		post.PublishAt = nil
+	case PostStatusScheduled:
+		// 只修改其他字段时保留原来的计划时间
+		if publishAt == nil && post.Status == PostStatusScheduled {
+			publishAt = post.PublishAt
+		}
+		if publishAt == nil {
+			return errValidation(FieldError{Field: "publish_at", Code: "required"})
+		}
+		if !publishAt.After(now) {
+			return errValidation(FieldError{Field: "publish_at", Code: "future"})
+		}
+		at := publishAt.UTC()
+		post.PublishAt = &at
+	case PostStatusPublished:
+		// 重新发布归档的文章时保留最初的发布时间
+		if post.PublishAt == nil || (post.Status != PostStatusPublished && post.Status != PostStatusArchived) {
+			post.PublishAt = &now
+		}
+	case PostStatusArchived:
+		if post.Status != PostStatusPublished && post.Status != PostStatusArchived {
+			return errCannotArchivePost
+		}
+	}
+	post.Status = status
+	return nil
+}
+
+// postPublisher 定时发布任务：把到达 publish_at 的 scheduled 文章改为 published
+// 计划保存在数据库中，服务重启后第一次检查就会补发停机期间到期的文章
+type postPublisher struct {
+	db   *gorm.DB
+	wake chan struct{}
+	now  func() time.Time
+}
+
+// newPostPublisher 创建定时发布任务
+func newPostPublisher(db *gorm.DB) *postPublisher {
+	return &postPublisher{
+		db:   db,
+		wake: make(chan struct{}, 1),
+		now:  dbNow,
+	}
+}
+
+// Wake 定时计划有变化时唤醒任务，重新计算下一次检查的时间
+func (p *postPublisher) Wake() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 循环发布到期的文章，直到 ctx 结束
+func (p *postPublisher) Run(ctx context.Context) {
+	for {
+		wait := publisherMaxWait
+		next, err := p.publishDue()
+		if err != nil {
+			log.Printf("publish scheduled posts: %v", err)
+		} else if next != nil {
+			if d := next.Sub(p.now()); d < wait {
+				wait = max(d, 0)
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-p.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// publishDue 发布所有已到期的文章，返回下一篇待发布文章的计划时间（没有时为 nil）
+func (p *postPublisher) publishDue() (*time.Time, error) {
+	var due []Post
+	if err := p.db.Select("id").
+		Where("status = ? AND publish_at <= ?", PostStatusScheduled, p.now()).
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+	if len(due) > 0 {
+		// 按主键更新，检索索引的回调据此收录文章；以状态为条件，作者同时改回草稿时不会被覆盖
+		// 状态变化也是文章的修改，版本号加1，之前取得的 ETag 随之失效
+		result := p.db.Model(&due).Where("status = ?", PostStatusScheduled).Updates(map[string]interface{}{
+			"status":  PostStatusPublished,
+			"version": gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		log.Printf("published %d scheduled posts", result.RowsAffected)
+	}
+
+	var next []Post
+	if err := p.db.Select("publish_at").
+		Where("status = ?", PostStatusScheduled).
+		Order("publish_at").
+		Limit(1).
+		Find(&next).Error; err != nil {
+		return nil, err
+	}
+	if len(next) == 0 {
+		return nil, nil
+	}
+	return next[0].PublishAt, nil
+}
+
+// RunPublisher 运行定时发布任务，阻塞直到 ctx 结束
+func (s *Server) RunPublisher(ctx context.Context) {
+	s.publisher.Run(ctx)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPublishDueInNonUTCZone 服务器不在 UTC 时区时，定时文章也要到 publish_at 才发布
+func TestPublishDueInNonUTCZone(t *testing.T) {
+	for _, offset := range []int{8 * 3600, -5 * 3600} {
+		withLocalZone(t, offset)
+		s, db := newTestServer(t)
+		alice := newTestClient(t, s)
+		alice.login("alice")
+
+		publishAt := time.Now().Add(3 * time.Hour).UTC().Format(time.RFC3339)
+		alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Later", "content": "content", "publish_at": publishAt})
+
+		if _, err := s.publisher.publishDue(); err != nil {
+			t.Fatal(err)
+		}
+		var post Post
+		db.First(&post, 1)
+		if post.Status != PostStatusScheduled {
+			t.Fatalf("offset %d: status = %q right after scheduling 3h ahead, want scheduled", offset, post.Status)
+		}
+
+		// 到期后发布
+		db.Model(&post).UpdateColumn("publish_at", dbNow().Add(-time.Minute))
+		if _, err := s.publisher.publishDue(); err != nil {
+			t.Fatal(err)
+		}
+		db.First(&post, 1)
+		if post.Status != PostStatusPublished {
+			t.Fatalf("offset %d: status = %q after publish_at passed, want published", offset, post.Status)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 可以配置限流规则的路由组，api 作用于全部接口
+var rateLimitGroups = []string{"api", "auth", "users", "posts", "comments", "admin"}
+
+// RateLimitResult 一次取令牌的结果
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	RetryAfter time.Duration // 被拒绝时，下一个令牌到来前需要等待的时间
+	Reset      time.Duration // 桶重新装满需要的时间
+}
+
+// RateLimitStore 令牌桶的存储
+// 默认使用进程内存储；多实例部署时可以实现基于Redis等共享存储的版本，在开始处理请求前通过 Server.SetRateLimitStore 替换
+type RateLimitStore interface {
+	// Take 从 key 对应的桶中取出一个令牌，桶不存在时按规则创建满桶
+	Take(key string, rule RateLimitRule) (RateLimitResult, error)
+}
+
+// tokenBucket 令牌桶状态
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // 在此之后桶已装满，可以清理
+}
+
+// memoryRateLimitStore 进程内的令牌桶存储
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// newMemoryRateLimitStore 创建进程内存储
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Take 按经过的时间补充令牌后取出一个
+func (m *memoryRateLimitStore) Take(key string, rule RateLimitRule) (RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweepLocked(now)
+
+	capacity := float64(rule.capacity())
+	rate := rule.rate()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{Limit: rule.capacity()}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsDuration((capacity - b.tokens) / rate)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweepLocked 每分钟清理一次已经装满的桶（与新建的桶没有区别），调用方需持有锁
+func (m *memoryRateLimitStore) sweepLocked(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// secondsDuration 把秒数转换为时长
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// capacity 桶容量，未配置 burst 时等于每周期的请求数
+func (r RateLimitRule) capacity() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Requests
+}
+
+// rate 每秒补充的令牌数
+func (r RateLimitRule) rate() float64 {
+	return float64(r.Requests) / time.Duration(r.Per).Seconds()
+}
+
+// appliesTo 规则是否作用于该请求方法
+func (r RateLimitRule) appliesTo(method string) bool {
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRateLimitStore 替换限流使用的存储（例如多实例共享的存储）
+func (s *Server) SetRateLimitStore(store RateLimitStore) {
+	s.rateLimits = store
+}
+
+// RateLimit 路由组的限流中间件
+// 携带有效访问令牌的请求按用户ID计数，其余按客户端IP计数；每个路由组的桶相互独立
+func (s *Server) RateLimit(group string) gin.HandlerFunc {
+	rule, ok := s.cfg.RateLimit.Groups[group]
+	if !s.cfg.RateLimit.Enabled || !ok || rule.Requests == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		if !rule.appliesTo(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		result, err := s.rateLimits.Take(group+":"+s.rateLimitKey(c), rule)
+		if err != nil {
+			// 存储不可用时放行，避免限流故障导致整个服务不可用
+			log.Printf("rate limit store: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			c.Error(errRateLimited)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey 限流计数的主体：有效访问令牌中的用户ID，否则为客户端IP
+// 这里只校验令牌签名，不查询吊销列表；令牌是否可用仍由 AuthMiddleware 判断
+func (s *Server) rateLimitKey(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		if claims, err := s.parseJWT(token); err == nil && claims.UserID != 0 {
+			return "user:" + strconv.FormatUint(uint64(claims.UserID), 10)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
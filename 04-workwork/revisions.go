@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/gorm"
+)
+
+// revisionListSpec 历史版本列表支持的参数（只分页，按版本号倒序）
+var revisionListSpec = &listSpec{
+	DefaultLimit: 20,
+}
+
+// revisionSnapshot 文章当前内容的快照（需预加载标签和分类），标签和分类按名称排序
+func revisionSnapshot(post Post) PostRevision {
+	tags := tagNames(post.Tags)
+	categories := categoryNames(post.Categories)
+	sort.Strings(tags)
+	sort.Strings(categories)
+	return PostRevision{
+		PostID:     post.ID,
+		Title:      post.Title,
+		Content:    post.Content,
+		Tags:       tags,
+		Categories: categories,
+	}
+}
+
+// sameContent 两个版本的标题、正文、标签和分类是否完全相同
+func (r PostRevision) sameContent(other PostRevision) bool {
+	return r.Title == other.Title &&
+		r.Content == other.Content &&
+		slices.Equal(r.Tags, other.Tags) &&
+		slices.Equal(r.Categories, other.Categories)
+}
+
+// recordRevision 在同一事务中保存文章当前内容的快照
+// 只修改状态等不影响内容的字段时与最新版本相同，不产生新版本
+func recordRevision(tx *gorm.DB, postID, editorID uint, restoredFrom *int) error {
+	var post Post
+	if err := tx.Unscoped().Preload("Tags").Preload("Categories").First(&post, postID).Error; err != nil {
+		return err
+	}
+
+	var latest []PostRevision
+	if err := tx.Where("post_id = ?", postID).Order("number desc").Limit(1).Find(&latest).Error; err != nil {
+		return err
+	}
+
+	revision := revisionSnapshot(post)
+	revision.Number = 1
+	if len(latest) > 0 {
+		if latest[0].sameContent(revision) {
+			return nil
+		}
+		revision.Number = latest[0].Number + 1
+	}
+	revision.EditorID = editorID
+	revision.RestoredFrom = restoredFrom
+	return tx.Create(&revision).Error
+}
+
+// backfillPostRevisions 为增加历史版本之前创建的文章（包括回收站中的）记录第一个版本
+// 编辑者为作者，时间取文章的最后修改时间
+func backfillPostRevisions(db *gorm.DB) error {
+	unscoped := db.Unscoped().Session(&gorm.Session{})
+	var posts []Post
+	return unscoped.Preload("Tags").Preload("Categories").
+		Where("NOT EXISTS (SELECT 1 FROM post_revisions WHERE post_revisions.post_id = posts.id)").
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				revision := revisionSnapshot(post)
+				revision.Number = 1
+				revision.EditorID = post.UserID
+				revision.CreatedAt = post.UpdatedAt
+				if err := unscoped.Create(&revision).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// revisionDocument 把版本转换为用于比较的文本：标题、标签、分类各占一行，空行后为正文
+func revisionDocument(revision PostRevision) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Title: %s\n", revision.Title)
+	fmt.Fprintf(&b, "Tags: %s\n", strings.Join(revision.Tags, ", "))
+	fmt.Fprintf(&b, "Categories: %s\n\n", strings.Join(revision.Categories, ", "))
+	b.WriteString(revision.Content)
+	return b.String()
+}
+
+// unifiedRevisionDiff 生成两个版本之间的 unified diff，内容相同时为空字符串
+func unifiedRevisionDiff(from, to PostRevision) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(revisionDocument(from)),
+		B:        difflib.SplitLines(revisionDocument(to)),
+		FromFile: fmt.Sprintf("revision %d", from.Number),
+		ToFile:   fmt.Sprintf("revision %d", to.Number),
+		Context:  3,
+	})
+}
+
+// findVisiblePost 按路径参数 id 查找当前用户可以查看的文章
+func (s *Server) findVisiblePost(c *gin.Context) (Post, error) {
+	var post Post
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return post, errInvalidPostID
+	}
+	if err := s.db.First(&post, postID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return post, errPostNotFound
+		}
+		return post, errInternal("Failed to fetch post", err)
+	}
+	if !post.visibleTo(getCurrentUserID(c)) {
+		return post, errPostNotFound
+	}
+	return post, nil
+}
+
+// findRevision 查找文章的指定版本并加载编辑者
+func (s *Server) findRevision(postID uint, number int) (PostRevision, error) {
+	var revision PostRevision
+	err := s.db.Preload("Editor", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "username", "nickname", "avatar")
+	}).Where("post_id = ? AND number = ?", postID, number).First(&revision).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return revision, errRevisionNotFound
+		}
+		return revision, errInternal("Failed to fetch revisions", err)
+	}
+	return revision, nil
+}
+
+// parseRevisionNumber 解析版本号参数，必须是正整数
+func parseRevisionNumber(value string) (int, bool) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, false
+	}
+	return number, true
+}
+
+// GetPostRevisions 文章的历史版本列表，按版本号倒序，不含正文
+func (s *Server) GetPostRevisions(c *gin.Context) {
+	post, err := s.findVisiblePost(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	params, err := parseListParams(c, revisionListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := s.db.Model(&PostRevision{}).Where("post_id = ?", post.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(errInternal("Failed to fetch revisions", err))
+		return
+	}
+
+	var revisions []PostRevision
+	if err := query.Preload("Editor", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "username", "nickname", "avatar")
+	}).Order("number desc").Offset(params.Offset()).Limit(params.Limit).Find(&revisions).Error; err != nil {
+		c.Error(errInternal("Failed to fetch revisions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Revisions retrieved successfully",
+		Data: gin.H{
+			"revisions": newPostRevisionListViews(revisions),
+			"pagination": gin.H{
+				"page":  params.Page,
+				"limit": params.Limit,
+				"total": total,
+			},
+		},
+	})
+}
+
+// GetPostRevision 文章某个历史版本的完整快照
+func (s *Server) GetPostRevision(c *gin.Context) {
+	post, err := s.findVisiblePost(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	number, ok := parseRevisionNumber(c.Param("rev"))
+	if !ok {
+		c.Error(errInvalidRevision)
+		return
+	}
+
+	revision, err := s.findRevision(post.ID, number)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Revision retrieved successfully",
+		Data:    newPostRevisionView(revision),
+	})
+}
+
+// DiffPostRevisions 比较文章的两个版本，返回 unified diff
+// to 默认为最新版本，from 默认为 to 的前一个版本
+func (s *Server) DiffPostRevisions(c *gin.Context) {
+	post, err := s.findVisiblePost(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var to int
+	if value := c.Query("to"); value != "" {
+		var ok bool
+		if to, ok = parseRevisionNumber(value); !ok {
+			c.Error(errValidation(FieldError{Field: "to", Code: "gte", Param: "1"}))
+			return
+		}
+	} else if err := s.db.Model(&PostRevision{}).Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(number), 0)").Scan(&to).Error; err != nil {
+		c.Error(errInternal("Failed to fetch revisions", err))
+		return
+	}
+
+	from := to - 1
+	if value := c.Query("from"); value != "" {
+		var ok bool
+		if from, ok = parseRevisionNumber(value); !ok {
+			c.Error(errValidation(FieldError{Field: "from", Code: "gte", Param: "1"}))
+			return
+		}
+	}
+	if from < 1 {
+		// 只有一个版本时没有可以比较的前一个版本
+		c.Error(errValidation(FieldError{Field: "from", Code: "required"}))
+		return
+	}
+
+	fromRevision, err := s.findRevision(post.ID, from)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	toRevision, err := s.findRevision(post.ID, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	diff, err := unifiedRevisionDiff(fromRevision, toRevision)
+	if err != nil {
+		c.Error(errInternal("Failed to fetch revisions", err))
+		return
+	}
+
+	fromView := newPostRevisionListViews([]PostRevision{fromRevision})[0]
+	toView := newPostRevisionListViews([]PostRevision{toRevision})[0]
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Revision diff generated successfully",
+		Data: gin.H{
+			"from": fromView,
+			"to":   toView,
+			"diff": diff,
+		},
+	})
+}
+
+// RestorePostRevision 把文章恢复为某个历史版本的标题、正文、标签和分类，只有作者可以操作
+// 恢复本身记录为一个新版本；状态和发布时间不变，已被删除的分类会被忽略
+func (s *Server) RestorePostRevision(c *gin.Context) {
+	post, err := s.findVisiblePost(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	userID := getCurrentUserID(c)
+	if post.UserID != userID {
+		c.Error(errCannotUpdatePost)
+		return
+	}
+	number, ok := parseRevisionNumber(c.Param("rev"))
+	if !ok {
+		c.Error(errInvalidRevision)
+		return
+	}
+
+	// If-Match 可选，提供时必须与当前版本一致
+	if c.GetHeader("If-Match") != "" {
+		if err := s.checkPostIfMatch(c, post); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	revision, err := s.findRevision(post.ID, number)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	titleChanged := post.Title != revision.Title
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := bumpPostVersion(tx, &post, map[string]interface{}{
+			"title":        revision.Title,
+			"content":      revision.Content,
+			"content_html": renderMarkdown(revision.Content),
+		}); err != nil {
+			return err
+		}
+		if titleChanged {
+			if err := assignSlug(tx, &post); err != nil {
+				return err
+			}
+		}
+
+		tags, err := resolveTags(tx, revision.Tags)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&post).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		categories := []Category{}
+		if len(revision.Categories) > 0 {
+			if err := tx.Where("name IN ?", revision.Categories).Find(&categories).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&post).Association("Categories").Replace(categories); err != nil {
+			return err
+		}
+
+		return recordRevision(tx, post.ID, userID, &revision.Number)
+	})
+	if errors.Is(err, errStalePost) {
+		c.Error(s.postModified(c, post.ID))
+		return
+	}
+	if err != nil {
+		c.Error(errInternal("Failed to restore revision", err))
+		return
+	}
+
+	// 重新查询以获取完整信息
+	s.db.Preload("User").Preload("Tags").Preload("Categories").First(&post, post.ID)
+	view := newPostView(post)
+	if counts, err := s.approvedCommentCounts([]Post{post}); err == nil {
+		view.CommentCount = counts[post.ID]
+	}
+
+	c.Header("ETag", postETag(post))
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Revision restored successfully",
+		Data:    view,
+	})
+}
@@ -0,0 +1,556 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// SearchBackendAuto MySQL下优先使用FULLTEXT，不可用时回退到进程内索引
+	SearchBackendAuto = "auto"
+	// SearchBackendMySQL 强制使用MySQL FULLTEXT
+	SearchBackendMySQL = "mysql"
+	// SearchBackendMemory 进程内倒排索引（SQLite/测试）
+	SearchBackendMemory = "memory"
+
+	// maxSearchCandidates 作者/日期/状态过滤之后最多保留的结果数，分页在这些结果上进行
+	maxSearchCandidates = 1000
+	// maxSearchScan 过滤后结果不足时最多从索引读取的命中数
+	maxSearchScan = 20000
+)
+
+// SearchHit 索引返回的命中结果
+type SearchHit struct {
+	PostID uint
+	Score  float64
+}
+
+// SearchDocument 文章的可检索内容（标题、正文和已审核评论）
+type SearchDocument struct {
+	PostID   uint
+	Title    string
+	Content  string
+	Comments []string
+}
+
+// SearchIndex 全文检索接口，按相关度从高到低返回候选文章
+type SearchIndex interface {
+	Search(query string, limit int) ([]SearchHit, error)
+}
+
+// DocumentIndex 需要由应用维护文档的索引（例如进程内倒排索引）
+// 数据库自带的索引（MySQL FULLTEXT）不需要实现
+type DocumentIndex interface {
+	SearchIndex
+	Index(doc SearchDocument)
+	Remove(postID uint)
+}
+
+// SearchResult 搜索结果
+type SearchResult struct {
+	Post       PostView         `json:"post"`
+	Score      float64          `json:"score"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights 命中片段，匹配词用 <mark> 包裹，其余内容已做HTML转义
+type SearchHighlights struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// newSearchIndex 根据配置和数据库驱动选择检索后端
+func newSearchIndex(cfg SearchConfig, driver string, db *gorm.DB) (SearchIndex, error) {
+	isMySQL := strings.ToLower(driver) == DriverMySQL || driver == ""
+
+	switch cfg.Backend {
+	case SearchBackendMySQL:
+		return newMySQLSearchIndex(db)
+	case SearchBackendAuto:
+		if isMySQL {
+			index, err := newMySQLSearchIndex(db)
+			if err == nil {
+				return index, nil
+			}
+			log.Println("MySQL FULLTEXT unavailable, falling back to in-process search index:", err)
+		}
+	}
+
+	index := newMemorySearchIndex()
+	if err := rebuildSearchIndex(db, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// rebuildSearchIndex 从数据库全量加载已发布的文章到进程内索引
+func rebuildSearchIndex(db *gorm.DB, index DocumentIndex) error {
+	var posts []Post
+	if err := db.Preload("Comments", "status = ?", CommentStatusApproved).Where("status = ?", PostStatusPublished).Find(&posts).Error; err != nil {
+		return err
+	}
+	for _, post := range posts {
+		index.Index(searchDocumentFromPost(post))
+	}
+	return nil
+}
+
+// searchDocumentFromPost 文章（需预加载评论）转换为检索文档
+func searchDocumentFromPost(post Post) SearchDocument {
+	doc := SearchDocument{PostID: post.ID, Title: post.Title, Content: post.Content}
+	for _, comment := range post.Comments {
+		doc.Comments = append(doc.Comments, comment.Content)
+	}
+	return doc
+}
+
+// searchQueueKey 事务上下文中待同步到检索索引的文章队列
+type searchQueueKey struct{}
+
+// searchQueue 事务中写入过的文章，提交之后再重新索引，回滚时丢弃
+type searchQueue struct {
+	mu      sync.Mutex
+	postIDs []uint
+}
+
+// add 记录需要重新索引的文章
+func (q *searchQueue) add(postID uint) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !slices.Contains(q.postIDs, postID) {
+		q.postIDs = append(q.postIDs, postID)
+	}
+}
+
+// transaction 在事务中执行 fn；事务中文章和评论的变化在提交之后才同步到检索索引
+// 所有写文章或评论的事务都应通过它执行，否则回滚的修改也会进入索引
+func (s *Server) transaction(fn func(tx *gorm.DB) error) error {
+	queue := &searchQueue{}
+	ctx := context.WithValue(s.db.Statement.Context, searchQueueKey{}, queue)
+	if err := s.db.WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+	if index, ok := s.search.(DocumentIndex); ok {
+		for _, postID := range queue.postIDs {
+			reindexPost(s.db, index, postID)
+		}
+	}
+	return nil
+}
+
+// reindexPost 重新读取文章并更新索引：只收录已发布的文章，改为草稿、归档或删除的文章从索引中移除
+func reindexPost(db *gorm.DB, index DocumentIndex, postID uint) {
+	var post Post
+	err := db.Preload("Comments", "status = ?", CommentStatusApproved).
+		Where("status = ?", PostStatusPublished).
+		First(&post, postID).Error
+	if err != nil {
+		index.Remove(postID)
+		return
+	}
+	index.Index(searchDocumentFromPost(post))
+}
+
+// registerSearchCallbacks 在数据库实例上注册回调，文章或评论写入后同步更新进程内索引
+// 回调按名称注册在 *gorm.DB 共享的回调链上，同一个连接只能对应一个进程内索引，
+// 重复注册会替换之前的回调，因此返回错误（使用进程内检索的 Server 需要各自的 *gorm.DB）
+// 通过 Server.transaction 执行的事务中只记录文章，提交之后再更新索引
+func registerSearchCallbacks(db *gorm.DB, index DocumentIndex) error {
+	if db.Callback().Create().Get("search:create") != nil {
+		return errors.New("search callbacks already registered on this database")
+	}
+
+	reindex := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.Schema == nil {
+			return
+		}
+		table := tx.Statement.Schema.Table
+		if table != "posts" && table != "comments" {
+			return
+		}
+
+		queue, _ := tx.Statement.Context.Value(searchQueueKey{}).(*searchQueue)
+		// 使用当前语句的连接（可能处于事务中）查找评论所属的文章
+		session := tx.Session(&gorm.Session{NewDB: true})
+		for _, id := range statementIDs(tx) {
+			postID := id
+			if table == "comments" {
+				var comment Comment
+				if err := session.Unscoped().Select("post_id").First(&comment, id).Error; err != nil {
+					continue
+				}
+				postID = comment.PostID
+			}
+
+			if queue != nil {
+				queue.add(postID)
+				continue
+			}
+			reindexPost(session, index, postID)
+		}
+	}
+
+	if err := db.Callback().Create().After("gorm:create").Register("search:create", reindex); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("search:update", reindex); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("search:delete", reindex)
+}
+
+// statementIDs 取出本次写操作涉及的主键（批量条件更新无法取得主键时返回空）
+func statementIDs(tx *gorm.DB) []uint {
+	field := tx.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return nil
+	}
+
+	var ids []uint
+	collect := func(v interface{}, zero bool) {
+		if id, ok := v.(uint); ok && !zero {
+			ids = append(ids, id)
+		}
+	}
+
+	rv := tx.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Struct:
+		collect(field.ValueOf(tx.Statement.Context, rv))
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elem := rv.Index(i)
+			for elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			collect(field.ValueOf(tx.Statement.Context, elem))
+		}
+	}
+	return ids
+}
+
+// SearchPosts 全文检索文章：GET /api/posts/search?q=&author=&since=&until=&page=&limit=
+func (s *Server) SearchPosts(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.Error(errValidation(FieldError{Field: "q", Code: "required"}))
+		return
+	}
+
+	params, err := parseListParams(c, searchListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	params.Statuses = []string{PostStatusPublished}
+
+	posts, scores, capped, err := s.searchCandidates(q, params)
+	if err != nil {
+		c.Error(errInternal("Failed to search posts", err))
+		return
+	}
+
+	total := len(posts)
+	start := params.Offset()
+	if start > total {
+		start = total
+	}
+	end := start + params.Limit
+	if end > total {
+		end = total
+	}
+
+	// 只为当前页加载作者、标签和分类
+	page, err := s.loadSearchPage(posts[start:end])
+	if err != nil {
+		c.Error(errInternal("Failed to fetch posts", err))
+		return
+	}
+	counts, err := s.approvedCommentCounts(page)
+	if err != nil {
+		c.Error(errInternal("Failed to fetch posts", err))
+		return
+	}
+
+	terms := highlightTerms(q)
+	results := make([]SearchResult, 0, len(page))
+	for _, post := range newPostListViews(page, counts) {
+		results = append(results, SearchResult{
+			Post:  post,
+			Score: scores[post.ID],
+			Highlights: SearchHighlights{
+				Title:   highlight(post.Title, terms),
+				Content: snippet(post.Content, terms, 60),
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Search completed successfully",
+		Data: gin.H{
+			"results": results,
+			"pagination": gin.H{
+				"page":   params.Page,
+				"limit":  params.Limit,
+				"total":  total,
+				"capped": capped,
+			},
+		},
+	})
+}
+
+// searchCandidates 从索引读取命中的文章，先应用作者、日期和状态过滤，再按相关度排序并截断到 maxSearchCandidates
+// 过滤后不足 maxSearchCandidates 条时继续向索引要更多命中，最多读取 maxSearchScan 条
+// capped 为 true 表示可能还有没有返回的结果，此时 total 只是下限
+func (s *Server) searchCandidates(q string, params *listParams) ([]Post, map[uint]float64, bool, error) {
+	scores := make(map[uint]float64)
+	var posts []Post
+	for limit := maxSearchCandidates; ; limit = min(limit*4, maxSearchScan) {
+		hits, err := s.search.Search(q, limit)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		// 只过滤上一轮之后新增的命中；只查询排序需要的字段
+		ids := make([]uint, 0, len(hits))
+		for _, hit := range hits {
+			if _, ok := scores[hit.PostID]; ok {
+				continue
+			}
+			scores[hit.PostID] = hit.Score
+			ids = append(ids, hit.PostID)
+		}
+		for chunk := range slices.Chunk(ids, maxSearchCandidates) {
+			var batch []Post
+			query := params.applyFilters(s.db.Model(&Post{}).Select("posts.id", "posts.created_at").Where("posts.id IN ?", chunk))
+			if err := query.Find(&batch).Error; err != nil {
+				return nil, nil, false, err
+			}
+			posts = append(posts, batch...)
+		}
+
+		exhausted := len(hits) < limit
+		if exhausted || len(posts) >= maxSearchCandidates || limit == maxSearchScan {
+			// 按相关度排序，相同分数时新文章在前
+			sort.SliceStable(posts, func(i, j int) bool {
+				if scores[posts[i].ID] != scores[posts[j].ID] {
+					return scores[posts[i].ID] > scores[posts[j].ID]
+				}
+				return posts[i].CreatedAt.After(posts[j].CreatedAt)
+			})
+			capped := !exhausted || len(posts) > maxSearchCandidates
+			if len(posts) > maxSearchCandidates {
+				posts = posts[:maxSearchCandidates]
+			}
+			return posts, scores, capped, nil
+		}
+	}
+}
+
+// loadSearchPage 加载一页结果的完整内容、作者、标签和分类，保持原来的顺序
+func (s *Server) loadSearchPage(page []Post) ([]Post, error) {
+	if len(page) == 0 {
+		return page, nil
+	}
+	ids := make([]uint, 0, len(page))
+	for _, post := range page {
+		ids = append(ids, post.ID)
+	}
+	var loaded []Post
+	if err := s.db.Preload("User").Preload("Tags").Preload("Categories").Find(&loaded, ids).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Post, len(loaded))
+	for _, post := range loaded {
+		byID[post.ID] = post
+	}
+	result := make([]Post, 0, len(page))
+	for _, post := range page {
+		if full, ok := byID[post.ID]; ok {
+			result = append(result, full)
+		}
+	}
+	return result, nil
+}
+
+// parseTimeParam 解析 RFC3339 时间或 YYYY-MM-DD 日期
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, time.Local)
+}
+
+// ==================== 分词与高亮 ====================
+
+// isHan 判断是否为中日韩统一表意文字
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// tokenize 分词：拉丁字母和数字按单词切分，中文按单字和相邻二元组切分
+// forQuery 为 true 时，多字中文只使用二元组，使查询更精确
+func tokenize(text string, forQuery bool) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) == 0 {
+			return
+		}
+		if !forQuery || len(han) == 1 {
+			for _, r := range han {
+				tokens = append(tokens, string(r))
+			}
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isHan(r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
+
+// highlightTerms 查询中用于高亮的词（按空白切分，忽略大小写）
+func highlightTerms(q string) [][]rune {
+	var terms [][]rune
+	for _, f := range strings.Fields(q) {
+		terms = append(terms, []rune(strings.ToLower(f)))
+	}
+	return terms
+}
+
+// matchRanges 找出文本中所有匹配词的位置（按rune计算，不重叠）
+func matchRanges(text []rune, terms [][]rune) [][2]int {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var ranges [][2]int
+	for i := 0; i < len(lower); {
+		matched := 0
+		for _, term := range terms {
+			if len(term) > matched && i+len(term) <= len(lower) && string(lower[i:i+len(term)]) == string(term) &&
+				atWordBoundary(lower, i, i+len(term)) {
+				matched = len(term)
+			}
+		}
+		if matched > 0 {
+			ranges = append(ranges, [2]int{i, i + matched})
+			i += matched
+		} else {
+			i++
+		}
+	}
+	return ranges
+}
+
+// atWordBoundary 拉丁字母/数字的匹配不能落在单词中间（中文不受限制）
+func atWordBoundary(text []rune, from, to int) bool {
+	isWord := func(r rune) bool {
+		return !isHan(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+	}
+	if from > 0 && isWord(text[from]) && isWord(text[from-1]) {
+		return false
+	}
+	if to < len(text) && isWord(text[to-1]) && isWord(text[to]) {
+		return false
+	}
+	return true
+}
+
+// markRanges 对片段做HTML转义并用 <mark> 包裹匹配部分
+func markRanges(text []rune, ranges [][2]int, from, to int) string {
+	var b strings.Builder
+	pos := from
+	for _, r := range ranges {
+		if r[1] <= from || r[0] >= to {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(text[pos:r[0]])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(text[r[0]:r[1]])))
+		b.WriteString("</mark>")
+		pos = r[1]
+	}
+	b.WriteString(html.EscapeString(string(text[pos:to])))
+	return b.String()
+}
+
+// highlight 高亮整段文本（用于标题）
+func highlight(text string, terms [][]rune) string {
+	runes := []rune(text)
+	return markRanges(runes, matchRanges(runes, terms), 0, len(runes))
+}
+
+// snippet 截取第一个匹配附近的片段并高亮（用于正文），没有匹配时返回开头部分
+func snippet(text string, terms [][]rune, radius int) string {
+	runes := []rune(text)
+	ranges := matchRanges(runes, terms)
+
+	center := 0
+	if len(ranges) > 0 {
+		center = ranges[0][0]
+	}
+	from := center - radius
+	if from < 0 {
+		from = 0
+	}
+	to := center + radius
+	if to > len(runes) {
+		to = len(runes)
+	}
+	// 匹配词不能被截断
+	for _, r := range ranges {
+		if r[0] < to && r[1] > to {
+			to = r[1]
+		}
+	}
+
+	out := markRanges(runes, ranges, from, to)
+	if from > 0 {
+		out = "…" + out
+	}
+	if to < len(runes) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// 各字段的权重：标题命中比正文更相关，评论命中最弱
+const (
+	searchWeightTitle   = 3.0
+	searchWeightContent = 1.0
+	searchWeightComment = 0.5
+)
+
+// memorySearchIndex 进程内倒排索引，用于SQLite和测试环境
+type memorySearchIndex struct {
+	mu       sync.RWMutex
+	docs     map[uint]map[string]float64 // 文章ID -> 词 -> 加权词频
+	postings map[string]map[uint]float64 // 词 -> 文章ID -> 加权词频
+}
+
+// newMemorySearchIndex 创建空的进程内索引
+func newMemorySearchIndex() *memorySearchIndex {
+	return &memorySearchIndex{
+		docs:     make(map[uint]map[string]float64),
+		postings: make(map[string]map[uint]float64),
+	}
+}
+
+// Index 新增或替换文章的索引
+func (m *memorySearchIndex) Index(doc SearchDocument) {
+	terms := make(map[string]float64)
+	for _, t := range tokenize(doc.Title, false) {
+		terms[t] += searchWeightTitle
+	}
+	for _, t := range tokenize(doc.Content, false) {
+		terms[t] += searchWeightContent
+	}
+	for _, comment := range doc.Comments {
+		for _, t := range tokenize(comment, false) {
+			terms[t] += searchWeightComment
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeLocked(doc.PostID)
+	m.docs[doc.PostID] = terms
+	for t, tf := range terms {
+		if m.postings[t] == nil {
+			m.postings[t] = make(map[uint]float64)
+		}
+		m.postings[t][doc.PostID] = tf
+	}
+}
+
+// Remove 从索引中删除文章
+func (m *memorySearchIndex) Remove(postID uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(postID)
+}
+
+func (m *memorySearchIndex) removeLocked(postID uint) {
+	for t := range m.docs[postID] {
+		delete(m.postings[t], postID)
+		if len(m.postings[t]) == 0 {
+			delete(m.postings, t)
+		}
+	}
+	delete(m.docs, postID)
+}
+
+// Search 按 TF-IDF 打分，返回得分最高的 limit 篇文章
+func (m *memorySearchIndex) Search(query string, limit int) ([]SearchHit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	scores := make(map[uint]float64)
+	total := float64(len(m.docs))
+	for _, t := range tokenize(query, true) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		posting := m.postings[t]
+		if len(posting) == 0 {
+			continue
+		}
+		idf := math.Log(1 + total/float64(len(posting)))
+		for postID, tf := range posting {
+			scores[postID] += (1 + math.Log(tf)) * idf
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for postID, score := range scores {
+		hits = append(hits, SearchHit{PostID: postID, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].PostID > hits[j].PostID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
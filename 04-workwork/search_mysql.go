@@ -0,0 +1,65 @@
+package main
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	mysqlPostsFulltextIndex    = "idx_posts_fulltext"
+	mysqlCommentsFulltextIndex = "idx_comments_fulltext"
+)
+
+// mysqlSearchIndex 基于MySQL FULLTEXT（ngram分词，支持中文）的检索
+// 索引由数据库维护，写入时无需额外处理
+type mysqlSearchIndex struct {
+	db *gorm.DB
+}
+
+// newMySQLSearchIndex 确保FULLTEXT索引存在
+func newMySQLSearchIndex(db *gorm.DB) (*mysqlSearchIndex, error) {
+	migrator := db.Migrator()
+	if !migrator.HasIndex(&Post{}, mysqlPostsFulltextIndex) {
+		if err := db.Exec("CREATE FULLTEXT INDEX " + mysqlPostsFulltextIndex + " ON posts (title, content) WITH PARSER ngram").Error; err != nil {
+			return nil, err
+		}
+	}
+	if !migrator.HasIndex(&Comment{}, mysqlCommentsFulltextIndex) {
+		if err := db.Exec("CREATE FULLTEXT INDEX " + mysqlCommentsFulltextIndex + " ON comments (content) WITH PARSER ngram").Error; err != nil {
+			return nil, err
+		}
+	}
+	return &mysqlSearchIndex{db: db}, nil
+}
+
+// Search 标题/正文相关度加上已审核评论相关度的一半
+func (m *mysqlSearchIndex) Search(query string, limit int) ([]SearchHit, error) {
+	var rows []struct {
+		ID    uint
+		Score float64
+	}
+	err := m.db.Raw(`
+SELECT p.id AS id,
+       MATCH(p.title, p.content) AGAINST (? IN NATURAL LANGUAGE MODE) + COALESCE(cm.score, 0) * ? AS score
+FROM posts p
+LEFT JOIN (
+    SELECT post_id, SUM(MATCH(content) AGAINST (? IN NATURAL LANGUAGE MODE)) AS score
+    FROM comments
+    WHERE deleted_at IS NULL AND status = ? AND MATCH(content) AGAINST (? IN NATURAL LANGUAGE MODE)
+    GROUP BY post_id
+) cm ON cm.post_id = p.id
+WHERE p.deleted_at IS NULL AND p.status = ?
+  AND (MATCH(p.title, p.content) AGAINST (? IN NATURAL LANGUAGE MODE) OR cm.score IS NOT NULL)
+ORDER BY score DESC
+LIMIT ?`,
+		query, searchWeightComment, query, CommentStatusApproved, query, PostStatusPublished, query, limit,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(rows))
+	for _, row := range rows {
+		hits = append(hits, SearchHit{PostID: row.ID, Score: row.Score})
+	}
+	return hits, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TestSearchIndexFollowsCommittedTransactions 事务回滚时检索索引不变，提交之后才更新
+func TestSearchIndexFollowsCommittedTransactions(t *testing.T) {
+	s, _ := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Apple", "content": "fruit"})
+
+	total := func(q string) int {
+		t.Helper()
+		rec := alice.mustDo(http.StatusOK, http.MethodGet, "/api/posts/search?q="+q, nil)
+		var resp struct {
+			Data struct {
+				Pagination struct {
+					Total int `json:"total"`
+				} `json:"pagination"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data.Pagination.Total
+	}
+	rename := func(tx *gorm.DB) error {
+		var post Post
+		post.ID = 1
+		return tx.Model(&post).Update("title", "Zebra").Error
+	}
+
+	errRollback := errors.New("rollback")
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := rename(tx); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("transaction error = %v", err)
+	}
+	if got := total("zebra"); got != 0 {
+		t.Fatalf("rolled back title is searchable: %d results", got)
+	}
+	if got := total("apple"); got != 1 {
+		t.Fatalf("original title results = %d, want 1", got)
+	}
+
+	if err := s.transaction(rename); err != nil {
+		t.Fatal(err)
+	}
+	if got := total("zebra"); got != 1 {
+		t.Fatalf("committed title results = %d, want 1", got)
+	}
+}
+
+// TestSearchFiltersBeforeCap 过滤条件在截断候选集之前应用：排在前 maxSearchCandidates 之外的文章也能按作者找到
+func TestSearchFiltersBeforeCap(t *testing.T) {
+	s, db := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+
+	bulk := User{Username: "bulk", Email: "bulk@example.com", Password: "x"}
+	if err := db.Create(&bulk).Error; err != nil {
+		t.Fatal(err)
+	}
+	posts := make([]Post, maxSearchCandidates)
+	for i := range posts {
+		posts[i] = Post{Title: "common common", Slug: fmt.Sprintf("bulk-%d", i), Content: "common", UserID: bulk.ID, Status: PostStatusPublished}
+	}
+	if err := db.CreateInBatches(&posts, 100).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 相关度低于上面所有文章
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Other", "content": "common"})
+
+	search := func(query string) (total int, capped bool) {
+		t.Helper()
+		rec := alice.mustDo(http.StatusOK, http.MethodGet, "/api/posts/search?q=common"+query, nil)
+		var resp struct {
+			Data struct {
+				Pagination struct {
+					Total  int  `json:"total"`
+					Capped bool `json:"capped"`
+				} `json:"pagination"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data.Pagination.Total, resp.Data.Pagination.Capped
+	}
+
+	if total, capped := search("&author=alice"); total != 1 || capped {
+		t.Fatalf("author filter: total = %d, capped = %v, want 1 and false", total, capped)
+	}
+	if total, capped := search(""); total != maxSearchCandidates || !capped {
+		t.Fatalf("unfiltered: total = %d, capped = %v, want %d and true", total, capped, maxSearchCandidates)
+	}
+}
+
+// TestSearchIndexDropsDeletedComments 删除评论（包括回复）后评论内容不再能检索到
+func TestSearchIndexDropsDeletedComments(t *testing.T) {
+	s, db := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+	moderator := newTestClient(t, s)
+	moderator.loginAs(db, "mod", RoleModerator)
+
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Post", "content": "content"})
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "zebrafish"})
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "axolotl", "parent_id": 1})
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "platypus"})
+
+	total := func(q string) int {
+		t.Helper()
+		rec := alice.mustDo(http.StatusOK, http.MethodGet, "/api/posts/search?q="+q, nil)
+		var resp struct {
+			Data struct {
+				Pagination struct {
+					Total int `json:"total"`
+				} `json:"pagination"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data.Pagination.Total
+	}
+	for _, q := range []string{"zebrafish", "axolotl", "platypus"} {
+		if got := total(q); got != 1 {
+			t.Fatalf("%s before delete: %d results, want 1", q, got)
+		}
+	}
+
+	alice.mustDo(http.StatusOK, http.MethodDelete, "/api/comments/1", nil)
+	for _, q := range []string{"zebrafish", "axolotl"} {
+		if got := total(q); got != 0 {
+			t.Fatalf("%s after delete: %d results, want 0", q, got)
+		}
+	}
+
+	moderator.mustDo(http.StatusOK, http.MethodDelete, "/api/admin/comments/3", nil)
+	if got := total("platypus"); got != 0 {
+		t.Fatalf("platypus after admin delete: %d results, want 0", got)
+	}
+}
+
+// TestSearchCallbacksRegisteredOnce 同一个数据库连接上不能再创建第二个使用进程内检索的 Server，
+// 否则后注册的回调会替换前一个实例的索引回调
+func TestSearchCallbacksRegisteredOnce(t *testing.T) {
+	_, db := newTestServer(t)
+	if _, err := NewServer(testConfig(), db); err == nil {
+		t.Fatal("second server on the same database: want error, got nil")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Server 博客服务实例，持有配置、数据库连接和路由，处理函数都挂在它上面
+// 同一进程内可以创建多个互相隔离的实例（例如测试中各自使用独立的SQLite内存库）
+type Server struct {
+	cfg         *Config
+	db          *gorm.DB
+	keys        *KeyRing
+	search      SearchIndex
+	cursors     *cursorCodec
+	passwords   *passwordPolicy
+	logins      *loginLimiter
+	mailer      Mailer
+	emailTokens *emailTokenCodec
+	rateLimits  RateLimitStore
+	publisher   *postPublisher
+	router      *gin.Engine
+}
+
+// NewServer 创建博客服务并注册全部路由
+func NewServer(cfg *Config, db *gorm.DB) (*Server, error) {
+	keys, err := NewKeyRing(cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := newSearchIndex(cfg.Search, cfg.Database.Driver, db)
+	if err != nil {
+		return nil, err
+	}
+	if index, ok := search.(DocumentIndex); ok {
+		if err := registerSearchCallbacks(db, index); err != nil {
+			return nil, err
+		}
+	}
+
+	cursors, err := newCursorCodec(cfg.Pagination.CursorSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	passwords, err := newPasswordPolicy(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+
+	emailTokens, err := newEmailTokenCodec(cfg.Auth.EmailTokenSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		cfg:         cfg,
+		db:          db,
+		keys:        keys,
+		search:      search,
+		cursors:     cursors,
+		passwords:   passwords,
+		logins:      newLoginLimiter(cfg.Auth.Login),
+		mailer:      mailer,
+		emailTokens: emailTokens,
+		rateLimits:  newMemoryRateLimitStore(),
+		publisher:   newPostPublisher(db),
+	}
+	s.router = NewRouter(s)
+	if err := s.router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted_proxies: %w", err)
+	}
+	return s, nil
+}
+
+// Router 返回服务的Gin路由，可直接用于 httptest
+func (s *Server) Router() *gin.Engine {
+	return s.router
+}
+
+// Run 在指定地址启动HTTP服务
+func (s *Server) Run(addr string) error {
+	return s.router.Run(addr)
+}
+
+// NewRouter 创建Gin路由并把所有API挂到对应的处理方法上
+func NewRouter(s *Server) *gin.Engine {
+	r := gin.New()
+
+	// 添加中间件
+	// ErrorHandler 在 Recovery 外层，panic 也按统一的错误格式返回
+	r.Use(gin.Logger())
+	r.Use(ErrorHandler())
+	r.Use(gin.CustomRecovery(recoveryHandler))
+
+	r.NoRoute(func(c *gin.Context) {
+		c.Error(errRouteNotFound)
+	})
+
+	// 设置路由组
+	// 每个路由组可以单独配置限流规则，api 组的规则作用于全部接口
+	api := r.Group("/api", s.RateLimit("api"))
+	{
+		// 用户认证相关路由
+		auth := api.Group("/auth", s.RateLimit("auth"))
+		{
+			auth.POST("/register", s.Register)
+			auth.POST("/login", s.Login)
+			auth.POST("/refresh", s.Refresh)
+			auth.POST("/logout", s.AuthMiddleware(), s.Logout)
+			auth.POST("/verify", s.VerifyEmail)                                   // 验证邮箱
+			auth.POST("/verify/resend", s.AuthMiddleware(), s.ResendVerification) // 重新发送验证邮件
+			auth.POST("/password-reset", s.RequestPasswordReset)                  // 申请重置密码
+			auth.POST("/password-reset/confirm", s.ResetPassword)                 // 设置新密码
+		}
+
+		// 用户相关路由
+		users := api.Group("/users", s.RateLimit("users"))
+		{
+			users.GET("/me", s.AuthMiddleware(), s.GetMe)                   // 当前用户信息
+			users.GET("/me/posts", s.AuthMiddleware(), s.GetMyPosts)        // 自己的全部文章（含草稿）
+			users.PATCH("/me", s.AuthMiddleware(), s.UpdateMe)              // 修改个人资料
+			users.PUT("/me/password", s.AuthMiddleware(), s.ChangePassword) // 修改密码
+			users.DELETE("/me", s.AuthMiddleware(), s.DeleteMe)             // 注销账户
+			users.GET("/:id", s.GetUser)                                    // 用户主页
+			users.GET("/:id/posts", s.GetUserPosts)                         // 用户的文章列表
+		}
+
+		// 标签和分类
+		api.GET("/tags", s.GetTags)             // 标签列表（按文章数排序）
+		api.GET("/categories", s.GetCategories) // 分类列表
+
+		// 文章相关路由
+		posts := api.Group("/posts", s.RateLimit("posts"))
+		{
+			posts.GET("", s.GetPosts)                                                // 获取所有文章
+			posts.GET("/search", s.SearchPosts)                                      // 全文检索文章
+			posts.GET("/trash", s.AuthMiddleware(), s.GetTrash)                      // 回收站
+			posts.GET("/by-slug/:slug", s.OptionalAuthMiddleware(), s.GetPostBySlug) // 通过 slug 获取文章，旧 slug 跳转到当前地址
+			posts.GET("/:id", s.OptionalAuthMiddleware(), s.GetPost)                 // 获取单个文章（作者可查看未发布的文章）
+			posts.POST("", s.AuthMiddleware(), s.CreatePost)                         // 创建文章
+			posts.PUT("/:id", s.AuthMiddleware(), s.UpdatePost)                      // 更新文章
+			posts.DELETE("/:id", s.AuthMiddleware(), s.DeletePost)                   // 删除文章（移入回收站）
+			posts.POST("/:id/restore", s.AuthMiddleware(), s.RestorePost)            // 从回收站恢复
+
+			// 历史版本
+			posts.GET("/:id/revisions", s.OptionalAuthMiddleware(), s.GetPostRevisions)          // 版本列表
+			posts.GET("/:id/revisions/diff", s.OptionalAuthMiddleware(), s.DiffPostRevisions)    // 比较两个版本
+			posts.GET("/:id/revisions/:rev", s.OptionalAuthMiddleware(), s.GetPostRevision)      // 获取某个版本
+			posts.POST("/:id/revisions/:rev/restore", s.AuthMiddleware(), s.RestorePostRevision) // 恢复到某个版本（仅作者）
+		}
+
+		// 评论相关路由
+		comments := api.Group("/comments", s.RateLimit("comments"))
+		{
+			comments.GET("/post/:postId", s.OptionalAuthMiddleware(), s.GetComments) // 获取文章评论
+			comments.POST("", s.AuthMiddleware(), s.CreateComment)                   // 创建评论
+			comments.PUT("/:id", s.AuthMiddleware(), s.UpdateComment)                // 编辑评论
+			comments.DELETE("/:id", s.AuthMiddleware(), s.DeleteComment)             // 删除评论
+		}
+
+		// 管理相关路由（需要管理员或版主角色）
+		admin := api.Group("/admin", s.RateLimit("admin"), s.AuthMiddleware())
+		{
+			admin.GET("/users", RequireRole(RoleAdmin), s.AdminListUsers)                             // 用户列表
+			admin.PUT("/users/:id/role", RequireRole(RoleAdmin), s.AdminUpdateUserRole)               // 修改用户角色
+			admin.DELETE("/posts/:id", RequireRole(RoleAdmin), s.AdminDeletePost)                     // 下架文章
+			admin.POST("/posts/purge", RequireRole(RoleAdmin), s.AdminPurgeTrash)                     // 彻底删除超过保留期的文章
+			admin.POST("/categories", RequireRole(RoleAdmin), s.AdminCreateCategory)                  // 创建分类
+			admin.DELETE("/categories/:id", RequireRole(RoleAdmin), s.AdminDeleteCategory)            // 删除分类
+			admin.GET("/comments", RequireRole(RoleModerator), s.AdminListComments)                   // 评论审核队列
+			admin.PUT("/comments/:id/status", RequireRole(RoleModerator), s.AdminUpdateCommentStatus) // 审核评论
+			admin.DELETE("/comments/:id", RequireRole(RoleModerator), s.AdminDeleteComment)           // 删除评论
+			admin.GET("/audit-logs", RequireRole(RoleAdmin), s.AdminListAuditLogs)                    // 审计记录
+		}
+	}
+
+	return r
+}
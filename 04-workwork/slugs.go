@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+const (
+	maxSlugLength   = 80     // 自动生成的 slug 最大长度（不含冲突后缀）
+	defaultPostSlug = "post" // 标题中没有可用字符时使用的 slug
+)
+
+// slugify 把标题转换为 slug：英文转小写，汉字转为不带声调的拼音，其余字符作为分隔符
+// 例如 "Go 语言入门" -> "go-yu-yan-ru-men"
+func slugify(title string) string {
+	args := pinyin.NewArgs()
+	var (
+		words []string
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	// 分解后去掉附加符号，é -> e
+	for _, r := range norm.NFD.String(title) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := pinyin.SinglePinyin(r, args); len(py) > 0 {
+				words = append(words, asciiSlugWord(py[0]))
+			}
+		case unicode.Is(unicode.Mn, r):
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	// 在单词边界截断
+	var b strings.Builder
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		if b.Len() > 0 && b.Len()+1+len(w) > maxSlugLength {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(w)
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+	}
+	if slug == "" {
+		return defaultPostSlug
+	}
+	return slug
+}
+
+// asciiSlugWord 拼音中的 ü 写作 v，其余非字母字符去掉
+func asciiSlugWord(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == 'ü':
+			b.WriteByte('v')
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// assignSlug 根据标题为文章分配 slug，与其他文章用过的 slug 冲突时追加 -2、-3 ...
+// 文章自己用过的 slug 可以重新使用；旧的 slug 保留在历史中，继续指向这篇文章
+func assignSlug(tx *gorm.DB, post *Post) error {
+	base := slugify(post.Title)
+
+	var used []PostSlug
+	if err := tx.Where("slug = ? OR slug LIKE ?", base, base+"-%").Find(&used).Error; err != nil {
+		return err
+	}
+	owners := make(map[string]uint, len(used))
+	for _, s := range used {
+		owners[s.Slug] = s.PostID
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		owner, ok := owners[slug]
+		if !ok || owner == post.ID {
+			break
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	if slug == post.Slug {
+		return nil
+	}
+
+	if _, ok := owners[slug]; !ok {
+		if err := tx.Create(&PostSlug{PostID: post.ID, Slug: slug}).Error; err != nil {
+			return err
+		}
+	}
+	post.Slug = slug
+	return tx.Model(post).UpdateColumn("slug", slug).Error
+}
+
+// backfillPostSlugs 为增加 slug 之前创建的文章（包括回收站中的）生成 slug
+func backfillPostSlugs(db *gorm.DB) error {
+	unscoped := db.Unscoped().Session(&gorm.Session{})
+	var posts []Post
+	return unscoped.Select("id", "title", "slug").Where("slug IS NULL OR slug = ''").
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for i := range posts {
+				if err := assignSlug(unscoped, &posts[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// GetPostBySlug 通过 slug 获取文章详情
+// 使用文章改名前的旧 slug 时返回 301，Location 指向当前的地址
+func (s *Server) GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var record PostSlug
+	if err := s.db.Where("slug = ?", slug).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errPostNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch post", err))
+		}
+		return
+	}
+
+	var post Post
+	if err := s.postDetailQuery().First(&post, record.PostID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errPostNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch post", err))
+		}
+		return
+	}
+	if !post.visibleTo(getCurrentUserID(c)) {
+		c.Error(errPostNotFound)
+		return
+	}
+
+	if post.Slug != slug {
+		location := url.URL{
+			Path:     strings.TrimSuffix(c.Request.URL.Path, slug) + post.Slug,
+			RawQuery: c.Request.URL.RawQuery,
+		}
+		c.Redirect(http.StatusMovedPermanently, location.String())
+		return
+	}
+
+	c.Header("ETag", postETag(post))
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Post retrieved successfully",
+		Data:    newPostView(post),
+	})
+}
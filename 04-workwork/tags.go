@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxPostTags       = 10 // 每篇文章最多的标签数
+	maxPostCategories = 3  // 每篇文章最多的分类数
+	maxTagLength      = 30 // 标签名称的最大字符数
+)
+
+// tagListSpec 标签列表支持的参数（只分页，按文章数排序）
+var tagListSpec = &listSpec{
+	DefaultLimit: 50,
+}
+
+// normalizeTag 规范化标签名称：去掉首尾空白，连续空白合并为一个空格，英文转为小写
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// normalizeTags 规范化并去重标签，忽略空标签
+func normalizeTags(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag := normalizeTag(name)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, errValidation(FieldError{Field: "tags", Code: "max", Param: strconv.Itoa(maxTagLength)})
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxPostTags {
+		return nil, errValidation(FieldError{Field: "tags", Code: "max_items", Param: strconv.Itoa(maxPostTags)})
+	}
+	return tags, nil
+}
+
+// resolveTags 查找标签，不存在的自动创建
+func resolveTags(tx *gorm.DB, names []string) ([]Tag, error) {
+	tags := make([]Tag, 0, len(names))
+	if len(names) == 0 {
+		return tags, nil
+	}
+	for _, name := range names {
+		tags = append(tags, Tag{Name: name})
+	}
+	// 并发创建同名标签时由唯一索引去重，之后统一按名称查询
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+	tags = tags[:0]
+	if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// resolveCategories 按名称查找分类，分类必须已经存在
+func resolveCategories(tx *gorm.DB, names []string) ([]Category, error) {
+	unique := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	if len(unique) > maxPostCategories {
+		return nil, errValidation(FieldError{Field: "categories", Code: "max_items", Param: strconv.Itoa(maxPostCategories)})
+	}
+
+	categories := make([]Category, 0, len(unique))
+	if len(unique) == 0 {
+		return categories, nil
+	}
+	if err := tx.Where("name IN ?", unique).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		found[category.Name] = true
+	}
+	for _, name := range unique {
+		if !found[name] {
+			return nil, errValidation(FieldError{Field: "categories", Code: "unknown", Param: name})
+		}
+	}
+	return categories, nil
+}
+
+// GetTags 标签列表，带有每个标签下已发布的文章数，按文章数倒序
+// 没有已发布文章的标签不返回
+func (s *Server) GetTags(c *gin.Context) {
+	params, err := parseListParams(c, tagListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := s.db.Model(&Tag{}).
+		Select("tags.id, tags.name, COUNT(posts.id) AS post_count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL AND posts.status = ?", PostStatusPublished).
+		Group("tags.id, tags.name")
+
+	var total int64
+	if err := s.db.Table("(?) AS t", query).Count(&total).Error; err != nil {
+		c.Error(errInternal("Failed to fetch tags", err))
+		return
+	}
+
+	tags := []TagView{}
+	if err := query.Order("post_count desc, tags.name asc").Offset(params.Offset()).Limit(params.Limit).Scan(&tags).Error; err != nil {
+		c.Error(errInternal("Failed to fetch tags", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Tags retrieved successfully",
+		Data: gin.H{
+			"tags": tags,
+			"pagination": gin.H{
+				"page":  params.Page,
+				"limit": params.Limit,
+				"total": total,
+			},
+		},
+	})
+}
+
+// GetCategories 全部分类，带有每个分类下已发布的文章数，按名称排序
+func (s *Server) GetCategories(c *gin.Context) {
+	categories := []CategoryView{}
+	err := s.db.Model(&Category{}).
+		Select("categories.id, categories.name, categories.description, COUNT(posts.id) AS post_count").
+		Joins("LEFT JOIN post_categories ON post_categories.category_id = categories.id").
+		Joins("LEFT JOIN posts ON posts.id = post_categories.post_id AND posts.deleted_at IS NULL AND posts.status = ?", PostStatusPublished).
+		Group("categories.id, categories.name, categories.description").
+		Order("categories.name asc").
+		Scan(&categories).Error
+	if err != nil {
+		c.Error(errInternal("Failed to fetch categories", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Categories retrieved successfully",
+		Data:    categories,
+	})
+}
+
+// AdminCreateCategory 管理员创建分类
+func (s *Server) AdminCreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.Error(errValidation(FieldError{Field: "name", Code: "required"}))
+		return
+	}
+
+	var count int64
+	if err := s.db.Model(&Category{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		c.Error(errInternal("Failed to create category", err))
+		return
+	}
+	if count > 0 {
+		c.Error(errCategoryExists)
+		return
+	}
+
+	category := Category{Name: name, Description: strings.TrimSpace(req.Description)}
+	if err := s.db.Create(&category).Error; err != nil {
+		c.Error(errInternal("Failed to create category", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Category created successfully",
+		Data:    CategoryView{ID: category.ID, Name: category.Name, Description: category.Description},
+	})
+}
+
+// AdminDeleteCategory 管理员删除分类，文章本身不受影响
+func (s *Server) AdminDeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+	categoryID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidCategoryID)
+		return
+	}
+
+	var deleted int64
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_categories WHERE category_id = ?", categoryID).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&Category{}, categoryID)
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		c.Error(errInternal("Failed to delete category", err))
+		return
+	}
+	if deleted == 0 {
+		c.Error(errCategoryNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Category deleted successfully",
+	})
+}
+
+// topicsError 分类校验错误原样返回，查询失败作为内部错误
+func topicsError(err error, message string) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return errInternal(message, err)
+}
+
+// tagNames 标签名称列表，没有标签时为空数组
+func tagNames(tags []Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// categoryNames 分类名称列表，没有分类时为空数组
+func categoryNames(categories []Category) []string {
+	names := make([]string, 0, len(categories))
+	for _, category := range categories {
+		names = append(names, category.Name)
+	}
+	return names
+}
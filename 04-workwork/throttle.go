@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// loginAttempts 某个用户名或IP的登录失败记录
+type loginAttempts struct {
+	failures int
+	last     time.Time // 最近一次失败的时间
+	until    time.Time // 在此之前拒绝登录
+	locked   bool      // until 来自锁定而不是退避
+}
+
+// loginLimiter 按用户名和IP统计登录失败次数（进程内）
+// 超过免费次数后每次失败都要等待指数增长的时间才能再次尝试，达到阈值后临时锁定
+type loginLimiter struct {
+	mu        sync.Mutex
+	cfg       LoginThrottleConfig
+	records   map[string]*loginAttempts
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// newLoginLimiter 创建登录限制器
+func newLoginLimiter(cfg LoginThrottleConfig) *loginLimiter {
+	return &loginLimiter{
+		cfg:     cfg,
+		records: make(map[string]*loginAttempts),
+		now:     time.Now,
+	}
+}
+
+// loginUserKey 用户名维度的键（不区分大小写，不存在的用户名同样计数，避免泄露用户是否存在）
+func loginUserKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+// loginIPKey IP维度的键
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check 返回需要等待的时间和是否处于锁定状态，多个键取最长的等待
+func (l *loginLimiter) Check(keys ...string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	var locked bool
+	for _, key := range keys {
+		rec, ok := l.records[key]
+		if !ok || !now.Before(rec.until) {
+			continue
+		}
+		if d := rec.until.Sub(now); d > wait {
+			wait = d
+		}
+		locked = locked || rec.locked
+	}
+	return wait, locked
+}
+
+// Fail 记录一次失败，threshold 为锁定阈值（<=0 表示不锁定）
+// 返回本次失败是否触发了锁定
+func (l *loginLimiter) Fail(key string, threshold int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweepLocked(now)
+
+	rec, ok := l.records[key]
+	if !ok {
+		rec = &loginAttempts{}
+		l.records[key] = rec
+	}
+	// 锁定结束或长时间没有失败后重新计数
+	if (rec.locked && !now.Before(rec.until)) || now.Sub(rec.last) > time.Duration(l.cfg.ResetAfter) {
+		*rec = loginAttempts{}
+	}
+
+	rec.failures++
+	rec.last = now
+	if threshold > 0 && rec.failures >= threshold {
+		rec.until = now.Add(time.Duration(l.cfg.LockoutDuration))
+		rec.locked = true
+		return true
+	}
+	if extra := rec.failures - l.cfg.FreeAttempts; extra > 0 {
+		rec.until = now.Add(l.backoff(extra))
+	}
+	return false
+}
+
+// Reset 登录成功后清除记录
+func (l *loginLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.records, key)
+}
+
+// backoff 第n次超出免费次数的失败需要等待 base * 2^(n-1)，不超过 max_delay
+func (l *loginLimiter) backoff(n int) time.Duration {
+	delay := time.Duration(l.cfg.BaseDelay)
+	maxDelay := time.Duration(l.cfg.MaxDelay)
+	for i := 1; i < n && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// sweepLocked 定期清理已过期的记录，防止内存无限增长；调用方需持有锁
+func (l *loginLimiter) sweepLocked(now time.Time) {
+	resetAfter := time.Duration(l.cfg.ResetAfter)
+	if now.Sub(l.lastSweep) < resetAfter {
+		return
+	}
+	l.lastSweep = now
+	for key, rec := range l.records {
+		if !now.Before(rec.until) && now.Sub(rec.last) > resetAfter {
+			delete(l.records, key)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// newTokenID 生成随机的jti/家族ID
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newRefreshTokenValue 生成返回给客户端的刷新令牌明文
+func newRefreshTokenValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken 数据库中只保存刷新令牌的SHA-256摘要
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// createRefreshToken 为用户创建一个属于指定家族的刷新令牌，返回明文
+func (s *Server) createRefreshToken(tx *gorm.DB, userID uint, familyID string) (string, error) {
+	value, err := newRefreshTokenValue()
+	if err != nil {
+		return "", err
+	}
+
+	rt := RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(value),
+		FamilyID:  familyID,
+		ExpiresAt: dbNow().Add(time.Duration(s.cfg.JWT.RefreshTokenTTL)),
+	}
+	if err := tx.Create(&rt).Error; err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// issueTokens 登录成功后签发访问令牌和一个新家族的刷新令牌
+func (s *Server) issueTokens(user User) (gin.H, error) {
+	accessToken, err := s.generateJWT(user)
+	if err != nil {
+		return nil, err
+	}
+
+	familyID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.createRefreshToken(s.db, user.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokenResponse(accessToken, refreshToken), nil
+}
+
+// tokenResponse 令牌相关的响应字段
+func (s *Server) tokenResponse(accessToken, refreshToken string) gin.H {
+	return gin.H{
+		"token":         accessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int64(time.Duration(s.cfg.JWT.TokenTTL).Seconds()),
+		"refresh_token": refreshToken,
+	}
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌，旧刷新令牌同时作废（轮换）
+// 已作废的刷新令牌再次出现说明可能被盗用，会吊销该家族的全部令牌
+func (s *Server) Refresh(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	var (
+		user            User
+		familyID        string
+		newRefreshToken string
+	)
+	now := dbNow()
+	err := s.transaction(func(tx *gorm.DB) error {
+		var rt RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(req.RefreshToken)).First(&rt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvalidRefreshToken
+			}
+			return err
+		}
+		familyID = rt.FamilyID
+
+		if rt.RevokedAt != nil {
+			return errRefreshTokenReused
+		}
+		if now.After(rt.ExpiresAt) {
+			return errInvalidRefreshToken
+		}
+
+		// 条件更新防止同一个刷新令牌被并发使用两次
+		result := tx.Model(&RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", rt.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return errRefreshTokenReused
+		}
+
+		if err := tx.First(&user, rt.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvalidRefreshToken
+			}
+			return err
+		}
+
+		var err error
+		newRefreshToken, err = s.createRefreshToken(tx, user.ID, rt.FamilyID)
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errRefreshTokenReused):
+			s.revokeRefreshTokenFamily(familyID)
+			c.Error(errUsedRefreshToken)
+		case errors.Is(err, errInvalidRefreshToken):
+			c.Error(errBadRefreshToken)
+		default:
+			c.Error(errInternal("Failed to refresh token", err))
+		}
+		return
+	}
+
+	accessToken, err := s.generateJWT(user)
+	if err != nil {
+		c.Error(errInternal("Failed to generate token", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Token refreshed successfully",
+		Data:    s.tokenResponse(accessToken, newRefreshToken),
+	})
+}
+
+// Logout 注销：吊销当前访问令牌，并可选吊销指定的刷新令牌或该用户全部刷新令牌
+func (s *Server) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(bindError(err))
+			return
+		}
+	}
+
+	userID := getCurrentUserID(c)
+	now := dbNow()
+
+	err := s.transaction(func(tx *gorm.DB) error {
+		// 吊销当前访问令牌
+		revoked := RevokedToken{
+			JTI:       getCurrentTokenID(c),
+			ExpiresAt: getCurrentTokenExpiry(c),
+		}
+		if err := tx.Create(&revoked).Error; err != nil {
+			return err
+		}
+
+		// 吊销刷新令牌（只能吊销自己的）
+		query := tx.Model(&RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		switch {
+		case req.All:
+		case req.RefreshToken != "":
+			query = query.Where("token_hash = ?", hashToken(req.RefreshToken))
+		default:
+			return nil
+		}
+		return query.Update("revoked_at", now).Error
+	})
+	if err != nil {
+		c.Error(errInternal("Failed to logout", err))
+		return
+	}
+
+	s.purgeExpiredTokens()
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Logout successful",
+	})
+}
+
+// isTokenRevoked 检查访问令牌是否已被吊销
+func (s *Server) isTokenRevoked(jti string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// isUserActive 检查用户是否存在且未注销
+func (s *Server) isUserActive(userID uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// revokeRefreshTokenFamily 吊销同一家族中所有仍有效的刷新令牌
+func (s *Server) revokeRefreshTokenFamily(familyID string) {
+	if familyID == "" {
+		return
+	}
+	s.db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", dbNow())
+}
+
+// purgeExpiredTokens 清理已过期的吊销记录和刷新令牌，过期后它们不再有意义
+func (s *Server) purgeExpiredTokens() {
+	now := dbNow()
+	s.db.Where("expires_at < ?", now).Delete(&RevokedToken{})
+	s.db.Unscoped().Where("expires_at < ?", now).Delete(&RefreshToken{})
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trashPost 把文章和它的评论一起移入回收站
+// 文章和评论使用同一个删除时间，恢复时只恢复随文章一起删除的评论，之前单独删除的评论保持删除
+func trashPost(tx *gorm.DB, post *Post, takenDown bool) error {
+	now := dbNow()
+	if err := tx.Model(&Comment{}).
+		Where("post_id = ?", post.ID).
+		UpdateColumn("deleted_at", now).Error; err != nil {
+		return err
+	}
+	// 最后更新文章本身，检索索引的回调据此移除文章
+	return tx.Model(post).UpdateColumns(map[string]interface{}{
+		"deleted_at": now,
+		"taken_down": takenDown,
+	}).Error
+}
+
+// GetTrash 当前用户回收站中的文章，按删除时间倒序
+func (s *Server) GetTrash(c *gin.Context) {
+	params, err := parseListParams(c, adminListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := s.db.Unscoped().Model(&Post{}).
+		Where("user_id = ? AND deleted_at IS NOT NULL", getCurrentUserID(c))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(errInternal("Failed to fetch trash", err))
+		return
+	}
+
+	var posts []Post
+	if err := query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "username", "nickname", "avatar")
+	}).Preload("Tags").Preload("Categories").Order("deleted_at desc, id desc").Offset(params.Offset()).Limit(params.Limit).Find(&posts).Error; err != nil {
+		c.Error(errInternal("Failed to fetch trash", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Trash retrieved successfully",
+		Data: gin.H{
+			"posts": newTrashedPostViews(posts, time.Duration(s.cfg.Trash.Retention)),
+			"pagination": gin.H{
+				"page":  params.Page,
+				"limit": params.Limit,
+				"total": total,
+			},
+		},
+	})
+}
+
+// RestorePost 从回收站恢复文章和随它一起删除的评论
+// 被管理员下架的文章不能由作者恢复
+func (s *Server) RestorePost(c *gin.Context) {
+	id := c.Param("id")
+	postID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidPostID)
+		return
+	}
+
+	var post Post
+	if err := s.db.Unscoped().Where("deleted_at IS NOT NULL").First(&post, postID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errPostNotInTrash)
+		} else {
+			c.Error(errInternal("Failed to fetch post", err))
+		}
+		return
+	}
+
+	if post.UserID != getCurrentUserID(c) {
+		c.Error(errCannotRestorePost)
+		return
+	}
+	if post.TakenDown {
+		c.Error(errPostTakenDown)
+		return
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		// 文章仍在回收站中，需要 Unscoped 才能更新版本号
+		if err := bumpPostVersion(tx.Unscoped(), &post, nil); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&Comment{}).
+			Where("post_id = ? AND deleted_at = ?", post.ID, post.DeletedAt.Time).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		// 最后恢复文章本身，检索索引的回调据此重新索引文章和评论
+		return tx.Unscoped().Model(&post).UpdateColumn("deleted_at", nil).Error
+	})
+	if errors.Is(err, errStalePost) {
+		c.Error(s.postModified(c, post.ID))
+		return
+	}
+	if err != nil {
+		c.Error(errInternal("Failed to restore post", err))
+		return
+	}
+
+	if err := s.db.Preload("User").Preload("Tags").Preload("Categories").First(&post, post.ID).Error; err != nil {
+		c.Error(errInternal("Failed to fetch post", err))
+		return
+	}
+	counts, err := s.approvedCommentCounts([]Post{post})
+	if err != nil {
+		c.Error(errInternal("Failed to fetch post", err))
+		return
+	}
+
+	c.Header("ETag", postETag(post))
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Post restored successfully",
+		Data:    newPostListViews([]Post{post}, counts)[0],
+	})
+}
+
+// AdminPurgeTrash 彻底删除在回收站中超过保留期的文章及其全部评论
+func (s *Server) AdminPurgeTrash(c *gin.Context) {
+	cutoff := dbNow().Add(-time.Duration(s.cfg.Trash.Retention))
+
+	var postIDs []uint
+	var purgedComments int64
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&Post{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &postIDs).Error; err != nil {
+			return err
+		}
+		if len(postIDs) == 0 {
+			return nil
+		}
+
+		result := tx.Unscoped().Where("post_id IN ?", postIDs).Delete(&Comment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purgedComments = result.RowsAffected
+		// 关联表没有软删除，随文章一起清除
+		for _, table := range []string{"post_tags", "post_categories", "post_slugs", "post_revisions"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE post_id IN ?", postIDs).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("id IN ?", postIDs).Delete(&Post{}).Error
+	})
+	if err != nil {
+		c.Error(errInternal("Failed to purge trash", err))
+		return
+	}
+
+	if len(postIDs) > 0 {
+		s.audit(c, AuditLog{
+			Action:   AuditTrashPurged,
+			UserID:   ptrUint(getCurrentUserID(c)),
+			Username: getCurrentUsername(c),
+			Detail:   fmt.Sprintf("purged %d posts and %d comments deleted before %s", len(postIDs), purgedComments, cutoff.Format(time.RFC3339)),
+		})
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Trash purged successfully",
+		Data: gin.H{
+			"purged_posts":    len(postIDs),
+			"purged_comments": purgedComments,
+			"deleted_before":  cutoff,
+		},
+	})
+}
+
+// ptrUint 返回指向 v 的指针
+func ptrUint(v uint) *uint {
+	return &v
+}
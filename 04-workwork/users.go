@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// usernamePattern 注册时用户名允许的字符：字母（包括汉字）、数字、下划线、连字符和点
+var usernamePattern = regexp.MustCompile(`^[\p{L}\p{N}_.-]+$`)
+
+// validUsername 用户名是否可以注册
+func validUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// deletedUsername 注销账号后使用的用户名，包含注册时不允许的 #，不会与真实用户冲突
+func deletedUsername(userID uint) string {
+	return fmt.Sprintf("deleted#%d", userID)
+}
+
+// GetUser 获取用户主页
+func (s *Server) GetUser(c *gin.Context) {
+	id := c.Param("id")
+	userID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var user User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+
+	var postCount int64
+	if err := s.db.Model(&Post{}).Where("user_id = ? AND status = ?", user.ID, PostStatusPublished).Count(&postCount).Error; err != nil {
+		c.Error(errInternal("Failed to fetch user", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "User retrieved successfully",
+		Data:    newUserProfileView(user, postCount),
+	})
+}
+
+// GetUserPosts 获取某个用户已发布的文章列表，支持与文章列表相同的查询参数
+func (s *Server) GetUserPosts(c *gin.Context) {
+	id := c.Param("id")
+	userID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var user User
+	if err := s.db.Select("id").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+
+	params, err := parseListParams(c, postListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	params.Author = strconv.FormatUint(uint64(user.ID), 10)
+	params.Statuses = []string{PostStatusPublished}
+
+	s.listPosts(c, params)
+}
+
+// GetMyPosts 获取当前用户的全部文章（包括草稿、定时发布和归档的文章）
+// 支持 status=draft,scheduled 按状态过滤，其余参数与文章列表相同
+func (s *Server) GetMyPosts(c *gin.Context) {
+	params, err := parseListParams(c, postListSpec, s.cursors)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	params.Author = strconv.FormatUint(uint64(getCurrentUserID(c)), 10)
+
+	if v := c.Query("status"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			status = strings.TrimSpace(status)
+			if !slices.Contains(postStatuses, status) {
+				c.Error(errValidation(FieldError{Field: "status", Code: "oneof", Param: strings.Join(postStatuses, " ")}))
+				return
+			}
+			params.Statuses = append(params.Statuses, status)
+		}
+	}
+
+	s.listPosts(c, params)
+}
+
+// GetMe 获取当前用户的账户信息
+func (s *Server) GetMe(c *gin.Context) {
+	var user User
+	if err := s.db.First(&user, getCurrentUserID(c)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "User retrieved successfully",
+		Data:    newUserAccountView(user),
+	})
+}
+
+// UpdateMe 修改当前用户的昵称、头像或邮箱
+// 修改邮箱后需要重新验证，验证邮件发往新邮箱
+func (s *Server) UpdateMe(c *gin.Context) {
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	var user User
+	if err := s.db.First(&user, getCurrentUserID(c)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Nickname != nil {
+		updates["nickname"] = *req.Nickname
+	}
+	if req.Avatar != nil {
+		if *req.Avatar != "" && !isHTTPURL(*req.Avatar) {
+			c.Error(errValidation(FieldError{Field: "avatar", Code: "url"}))
+			return
+		}
+		updates["avatar"] = *req.Avatar
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		// 修改邮箱需要验证当前密码
+		if req.CurrentPassword == "" {
+			c.Error(errValidation(FieldError{Field: "current_password", Code: "required"}))
+			return
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)) != nil {
+			c.Error(errWrongPassword)
+			return
+		}
+
+		var count int64
+		if err := s.db.Model(&User{}).Where("email = ? AND id <> ?", *req.Email, user.ID).Count(&count).Error; err != nil {
+			c.Error(errInternal("Failed to update user", err))
+			return
+		}
+		if count > 0 {
+			c.Error(errEmailTaken)
+			return
+		}
+		updates["email"] = *req.Email
+		updates["email_verified_at"] = nil
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&user).Updates(updates).Error; err != nil {
+			c.Error(errInternal("Failed to update user", err))
+			return
+		}
+	}
+
+	// 新邮箱需要重新验证
+	if _, changed := updates["email"]; changed {
+		if err := s.sendVerificationEmail(user); err != nil {
+			log.Printf("send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "User updated successfully",
+		Data:    newUserAccountView(user),
+	})
+}
+
+// ChangePassword 修改密码，需要提供旧密码
+// 修改后该用户所有的刷新令牌都会作废，其他设备需要重新登录
+func (s *Server) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	var user User
+	if err := s.db.First(&user, getCurrentUserID(c)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)) != nil {
+		c.Error(errWrongPassword)
+		return
+	}
+
+	if fields := s.passwords.Check("new_password", req.NewPassword); len(fields) > 0 {
+		c.Error(errValidation(fields...))
+		return
+	}
+
+	hashedPassword, err := s.passwords.Hash(req.NewPassword)
+	if err != nil {
+		c.Error(errInternal("Failed to hash password", err))
+		return
+	}
+
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return tx.Model(&RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", dbNow()).Error
+	})
+	if err != nil {
+		c.Error(errInternal("Failed to change password", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Password changed successfully",
+	})
+}
+
+// DeleteMe 注销当前账户，需要提供密码
+// 用户的文章（连同文章下的评论）和评论一并删除，令牌全部作废；
+// 用户名和邮箱会被替换，以便之后重新注册
+func (s *Server) DeleteMe(c *gin.Context) {
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	var user User
+	if err := s.db.First(&user, getCurrentUserID(c)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		c.Error(errWrongPassword)
+		return
+	}
+
+	err := s.transaction(func(tx *gorm.DB) error {
+		// 逐条删除（而不是按条件批量删除），让检索索引的回调能拿到主键
+		var posts []Post
+		if err := tx.Where("user_id = ?", user.ID).Find(&posts).Error; err != nil {
+			return err
+		}
+		postIDs := make([]uint, 0, len(posts))
+		for _, post := range posts {
+			postIDs = append(postIDs, post.ID)
+		}
+
+		// 用户的评论连同其他人对它们的回复一起删除，文章下的评论全部删除
+		var commentIDs []uint
+		query := tx.Model(&Comment{}).Where("user_id = ?", user.ID)
+		if len(postIDs) > 0 {
+			query = query.Or("post_id IN ?", postIDs)
+		}
+		if err := query.Pluck("id", &commentIDs).Error; err != nil {
+			return err
+		}
+		if err := deleteCommentThread(tx, commentIDs...); err != nil {
+			return err
+		}
+		if len(posts) > 0 {
+			if err := tx.Delete(&posts).Error; err != nil {
+				return err
+			}
+		}
+
+		now := dbNow()
+		if err := tx.Model(&RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&RevokedToken{JTI: getCurrentTokenID(c), ExpiresAt: getCurrentTokenExpiry(c)}).Error; err != nil {
+			return err
+		}
+
+		// 释放用户名和邮箱的唯一约束后软删除
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"username": deletedUsername(user.ID),
+			"email":    fmt.Sprintf("deleted_%d@invalid", user.ID),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
+		c.Error(errInternal("Failed to delete account", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Account deleted successfully",
+	})
+}
+
+// isHTTPURL 判断是否为 http/https 的绝对地址
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDeleteMe 注销账号后之前签发的令牌全部失效，用户名可以重新注册，且不会与真实用户名冲突
+func TestDeleteMe(t *testing.T) {
+	s, db := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+	other := newTestClient(t, s)
+	other.login("alice2")
+
+	// 另一个会话的访问令牌
+	otherSession := newTestClient(t, s)
+	otherSession.signIn("alice")
+	otherSession.mustDo(http.StatusOK, http.MethodGet, "/api/users/me", nil)
+
+	alice.mustDo(http.StatusOK, http.MethodDelete, "/api/users/me", gin.H{"password": "Passw0rd!x"})
+
+	// 注销时只吊销了当前请求的令牌，其他会话的令牌也不能再使用
+	otherSession.mustDo(http.StatusUnauthorized, http.MethodGet, "/api/users/me", nil)
+
+	var user User
+	db.Unscoped().Where("email LIKE ?", "deleted%").First(&user)
+	if user.Username != deletedUsername(user.ID) {
+		t.Fatalf("deleted account username = %q, want %q", user.Username, deletedUsername(user.ID))
+	}
+
+	anon := newTestClient(t, s)
+	anon.mustDo(http.StatusBadRequest, http.MethodPost, "/api/auth/register", gin.H{
+		"username": deletedUsername(user.ID), "email": "x@example.com", "password": "Passw0rd!x",
+	})
+	anon.mustDo(http.StatusCreated, http.MethodPost, "/api/auth/register", gin.H{
+		"username": "alice", "email": "alice@example.com", "password": "Passw0rd!x",
+	})
+	other.mustDo(http.StatusOK, http.MethodGet, "/api/users/me", nil)
+}
+
+// TestRegisterConfiguredAdminName 注册配置中的管理员用户名得到普通用户，只有启动时已存在的账号会被提升
+func TestRegisterConfiguredAdminName(t *testing.T) {
+	s, db := newTestServer(t)
+	s.cfg.AdminUsers = []string{"root"}
+
+	root := newTestClient(t, s)
+	root.login("root")
+	var user User
+	if err := db.Where("username = ?", "root").First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != RoleUser {
+		t.Fatalf("registered role = %q, want %q", user.Role, RoleUser)
+	}
+	root.mustDo(http.StatusForbidden, http.MethodGet, "/api/admin/users", nil)
+
+	// 启动时提升已存在的账号
+	if err := s.ensureAdmins(); err != nil {
+		t.Fatal(err)
+	}
+	root.signIn("root")
+	root.mustDo(http.StatusOK, http.MethodGet, "/api/admin/users", nil)
+}
+
+// TestDeleteMeDeletesReplies 注销账号时其他用户对该用户评论的回复一起删除，不留下父评论已删除的回复
+func TestDeleteMeDeletesReplies(t *testing.T) {
+	s, db := newTestServer(t)
+	alice := newTestClient(t, s)
+	alice.login("alice")
+	bob := newTestClient(t, s)
+	bob.login("bob")
+
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/posts", gin.H{"title": "Post", "content": "content"})
+	bob.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "bob's comment"})
+	alice.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "alice's reply", "parent_id": 1})
+	bob.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "reply to alice", "parent_id": 2})
+	bob.mustDo(http.StatusCreated, http.MethodPost, "/api/comments", gin.H{"post_id": 1, "content": "nested", "parent_id": 3})
+
+	bob.mustDo(http.StatusOK, http.MethodDelete, "/api/users/me", gin.H{"password": "Passw0rd!x"})
+
+	var remaining []uint
+	if err := db.Model(&Comment{}).Order("id").Pluck("id", &remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 0 {
+		t.Fatalf("remaining comments = %v, want none", remaining)
+	}
+}
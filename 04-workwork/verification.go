@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// emailLink 生成邮件中的链接：{mail.base_url}/{path}?token=...
+func (s *Server) emailLink(path, token string) string {
+	return strings.TrimRight(s.cfg.Mail.BaseURL, "/") + "/" + path + "?token=" + url.QueryEscape(token)
+}
+
+// formatTTL 把有效期格式化为邮件中使用的 "2天"、"1小时"、"30分钟"
+func formatTTL(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d天", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d小时", d/time.Hour)
+	default:
+		return fmt.Sprintf("%d分钟", (d+time.Minute-1)/time.Minute)
+	}
+}
+
+// sendVerificationEmail 给用户当前的邮箱发送验证链接
+func (s *Server) sendVerificationEmail(user User) error {
+	ttl := time.Duration(s.cfg.Auth.VerifyTokenTTL)
+	token := s.emailTokens.Issue(emailToken{
+		Purpose: EmailTokenVerify,
+		UserID:  user.ID,
+		Email:   user.Email,
+	}, ttl)
+
+	return s.mailer.Send(Mail{
+		From:    s.cfg.Mail.From,
+		To:      user.Email,
+		Subject: "验证你的邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请打开以下链接完成邮箱验证（%s内有效）：\n\n%s\n\n如果这不是你的操作，请忽略本邮件。\n",
+			user.Username, formatTTL(ttl), s.emailLink("verify-email", token)),
+	})
+}
+
+// sendPasswordResetEmail 发送重置密码链接，令牌绑定当前密码，使用一次后失效
+func (s *Server) sendPasswordResetEmail(user User) error {
+	ttl := time.Duration(s.cfg.Auth.ResetTokenTTL)
+	token := s.emailTokens.Issue(emailToken{
+		Purpose: EmailTokenReset,
+		UserID:  user.ID,
+		Email:   user.Email,
+		Stamp:   passwordStamp(user.Password),
+	}, ttl)
+
+	return s.mailer.Send(Mail{
+		From:    s.cfg.Mail.From,
+		To:      user.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，你好：\n\n我们收到了重置密码的申请，请打开以下链接设置新密码（%s内有效，只能使用一次）：\n\n%s\n\n如果这不是你的操作，请忽略本邮件，你的密码不会改变。\n",
+			user.Username, formatTTL(ttl), s.emailLink("reset-password", token)),
+	})
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+func (s *Server) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	tok, err := s.emailTokens.Parse(req.Token, EmailTokenVerify)
+	if err != nil {
+		c.Error(errBadVerifyToken)
+		return
+	}
+
+	var user User
+	if err := s.db.First(&user, tok.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errBadVerifyToken)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+	// 发送后修改过邮箱，旧链接作废
+	if !strings.EqualFold(user.Email, tok.Email) {
+		c.Error(errBadVerifyToken)
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := dbNow()
+		if err := s.db.Model(&user).Update("email_verified_at", now).Error; err != nil {
+			c.Error(errInternal("Failed to verify email", err))
+			return
+		}
+		user.EmailVerifiedAt = &now
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Email verified successfully",
+		Data:    newUserAccountView(user),
+	})
+}
+
+// ResendVerification 重新发送验证邮件给当前用户
+func (s *Server) ResendVerification(c *gin.Context) {
+	var user User
+	if err := s.db.First(&user, getCurrentUserID(c)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errUserNotFound)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.Error(errAlreadyVerified)
+		return
+	}
+
+	if err := s.sendVerificationEmail(user); err != nil {
+		c.Error(errInternal("Failed to send email", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Verification email sent",
+	})
+}
+
+// RequestPasswordReset 申请重置密码
+// 无论邮箱是否注册都返回相同的结果，避免被用来探测用户是否存在
+func (s *Server) RequestPasswordReset(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	var user User
+	err := s.db.Where("email = ?", req.Email).First(&user).Error
+	switch {
+	case err == nil:
+		if err := s.sendPasswordResetEmail(user); err != nil {
+			log.Printf("send password reset email to user %d: %v", user.ID, err)
+		}
+	case err != gorm.ErrRecordNotFound:
+		c.Error(errInternal("Failed to fetch user", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// ResetPassword 使用邮件中的令牌设置新密码
+// 成功后该用户的全部刷新令牌失效，登录失败计数清零，邮箱同时视为已验证
+func (s *Server) ResetPassword(c *gin.Context) {
+	var req PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	tok, err := s.emailTokens.Parse(req.Token, EmailTokenReset)
+	if err != nil {
+		c.Error(errBadResetToken)
+		return
+	}
+
+	var user User
+	if err := s.db.First(&user, tok.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(errBadResetToken)
+		} else {
+			c.Error(errInternal("Failed to fetch user", err))
+		}
+		return
+	}
+	// 密码或邮箱在发送后有变化（包括已经用这个链接重置过），链接作废
+	if !strings.EqualFold(user.Email, tok.Email) || passwordStamp(user.Password) != tok.Stamp {
+		c.Error(errBadResetToken)
+		return
+	}
+
+	if fields := s.passwords.Check("new_password", req.NewPassword); len(fields) > 0 {
+		c.Error(errValidation(fields...))
+		return
+	}
+
+	hashedPassword, err := s.passwords.Hash(req.NewPassword)
+	if err != nil {
+		c.Error(errInternal("Failed to hash password", err))
+		return
+	}
+
+	now := dbNow()
+	updates := map[string]interface{}{"password": hashedPassword}
+	if user.EmailVerifiedAt == nil {
+		updates["email_verified_at"] = now
+	}
+	err = s.transaction(func(tx *gorm.DB) error {
+		// 以旧密码哈希为条件更新，同一链接并发使用时只有一个请求能成功
+		result := tx.Model(&User{}).
+			Where("id = ? AND password = ?", user.ID, user.Password).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidEmailToken
+		}
+		return tx.Model(&RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error
+	})
+	if err == errInvalidEmailToken {
+		c.Error(errBadResetToken)
+		return
+	}
+	if err != nil {
+		c.Error(errInternal("Failed to reset password", err))
+		return
+	}
+
+	s.logins.Reset(loginUserKey(user.Username))
+	s.audit(c, AuditLog{Action: AuditPasswordReset, UserID: &user.ID, Username: user.Username})
+
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Password has been reset",
+	})
+}
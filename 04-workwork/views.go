@@ -0,0 +1,292 @@
+package main
+
+import "time"
+
+// 接口响应结构：处理函数只返回这些类型，不直接序列化GORM模型，
+// 避免泄露 DeletedAt、密码等内部字段，数据库结构调整也不会意外改变接口
+
+// UserPublicView 公开的用户信息（文章作者、评论作者）
+type UserPublicView struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname,omitempty"`
+	Avatar   string `json:"avatar,omitempty"`
+}
+
+// UserProfileView 用户主页
+type UserProfileView struct {
+	UserPublicView
+	CreatedAt time.Time `json:"created_at"`
+	PostCount int64     `json:"post_count"`
+}
+
+// UserAccountView 账户信息，只返回给用户本人和管理员
+type UserAccountView struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Verified  bool      `json:"email_verified"`
+	Role      string    `json:"role"`
+	Nickname  string    `json:"nickname"`
+	Avatar    string    `json:"avatar"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostView 文章
+// 列表中不包含评论，只返回已审核评论数；详情中 comments 为已审核的评论
+type PostView struct {
+	ID           uint           `json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Title        string         `json:"title"`
+	Slug         string         `json:"slug"`
+	Content      string         `json:"content"`
+	ContentHTML  string         `json:"content_html"`
+	UserID       uint           `json:"user_id"`
+	Status       string         `json:"status"`
+	PublishAt    *time.Time     `json:"publish_at"`
+	Version      int            `json:"version"`
+	Tags         []string       `json:"tags"`
+	Categories   []string       `json:"categories"`
+	Author       UserPublicView `json:"author"`
+	CommentCount int64          `json:"comment_count"`
+	Comments     []CommentView  `json:"comments,omitempty"`
+}
+
+// TrashedPostView 回收站中的文章
+// purge_at 之后文章可能被管理员彻底删除；taken_down 为 true 表示被管理员下架，不能自行恢复
+type TrashedPostView struct {
+	PostView
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+	TakenDown bool      `json:"taken_down"`
+}
+
+// PostRevisionView 文章的历史版本，列表中不返回 content
+type PostRevisionView struct {
+	Number       int            `json:"number"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Editor       UserPublicView `json:"editor"`
+	Title        string         `json:"title"`
+	Content      string         `json:"content,omitempty"`
+	Tags         []string       `json:"tags"`
+	Categories   []string       `json:"categories"`
+	RestoredFrom *int           `json:"restored_from"`
+}
+
+// TagView 标签及其下已发布的文章数
+type TagView struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+// CategoryView 分类及其下已发布的文章数
+type CategoryView struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	PostCount   int64  `json:"post_count"`
+}
+
+// CommentView 评论
+type CommentView struct {
+	ID          uint           `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Content     string         `json:"content"`
+	ContentHTML string         `json:"content_html"`
+	Status      string         `json:"status"`
+	PostID      uint           `json:"post_id"`
+	ParentID    *uint          `json:"parent_id"`
+	UserID      uint           `json:"user_id"`
+	Author      UserPublicView `json:"author"`
+}
+
+// CommentNode 树形评论节点
+type CommentNode struct {
+	CommentView
+	ReplyCount int64          `json:"reply_count"` // 直接回复总数（含未返回的部分）
+	Replies    []*CommentNode `json:"replies"`
+}
+
+// AuditLogView 审计记录
+type AuditLogView struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Action    string    `json:"action"`
+	UserID    *uint     `json:"user_id"`
+	Username  string    `json:"username"`
+	IP        string    `json:"ip"`
+	Detail    string    `json:"detail"`
+}
+
+// newUserPublicView 从用户模型生成公开信息
+func newUserPublicView(user User) UserPublicView {
+	return UserPublicView{ID: user.ID, Username: user.Username, Nickname: user.Nickname, Avatar: user.Avatar}
+}
+
+// newUserProfileView 生成用户主页信息
+func newUserProfileView(user User, postCount int64) UserProfileView {
+	return UserProfileView{
+		UserPublicView: newUserPublicView(user),
+		CreatedAt:      user.CreatedAt,
+		PostCount:      postCount,
+	}
+}
+
+// newUserAccountView 从用户模型生成账户信息
+func newUserAccountView(user User) UserAccountView {
+	return UserAccountView{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Verified:  user.EmailVerifiedAt != nil,
+		Role:      user.Role,
+		Nickname:  user.Nickname,
+		Avatar:    user.Avatar,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// newUserAccountViews 批量转换用户列表
+func newUserAccountViews(users []User) []UserAccountView {
+	views := make([]UserAccountView, 0, len(users))
+	for _, user := range users {
+		views = append(views, newUserAccountView(user))
+	}
+	return views
+}
+
+// newPostView 从文章模型生成详情，已预加载的评论一并转换
+func newPostView(post Post) PostView {
+	view := PostView{
+		ID:           post.ID,
+		CreatedAt:    post.CreatedAt,
+		UpdatedAt:    post.UpdatedAt,
+		Title:        post.Title,
+		Slug:         post.Slug,
+		Content:      post.Content,
+		ContentHTML:  post.ContentHTML,
+		UserID:       post.UserID,
+		Status:       post.Status,
+		PublishAt:    post.PublishAt,
+		Version:      post.Version,
+		Tags:         tagNames(post.Tags),
+		Categories:   categoryNames(post.Categories),
+		Author:       newUserPublicView(post.User),
+		CommentCount: int64(len(post.Comments)),
+	}
+	if len(post.Comments) > 0 {
+		view.Comments = newCommentViews(post.Comments)
+	}
+	return view
+}
+
+// newPostListViews 把文章模型转换为列表项，counts 为文章ID到评论数的映射
+func newPostListViews(posts []Post, counts map[uint]int64) []PostView {
+	views := make([]PostView, 0, len(posts))
+	for _, post := range posts {
+		view := newPostView(post)
+		view.CommentCount = counts[post.ID]
+		view.Comments = nil
+		views = append(views, view)
+	}
+	return views
+}
+
+// newPostRevisionView 把历史版本转换为响应结构（需预加载编辑者）
+func newPostRevisionView(revision PostRevision) PostRevisionView {
+	return PostRevisionView{
+		Number:       revision.Number,
+		CreatedAt:    revision.CreatedAt,
+		Editor:       newUserPublicView(revision.Editor),
+		Title:        revision.Title,
+		Content:      revision.Content,
+		Tags:         nonNilStrings(revision.Tags),
+		Categories:   nonNilStrings(revision.Categories),
+		RestoredFrom: revision.RestoredFrom,
+	}
+}
+
+// newPostRevisionListViews 转换历史版本列表，不含正文
+func newPostRevisionListViews(revisions []PostRevision) []PostRevisionView {
+	views := make([]PostRevisionView, 0, len(revisions))
+	for _, revision := range revisions {
+		view := newPostRevisionView(revision)
+		view.Content = ""
+		views = append(views, view)
+	}
+	return views
+}
+
+// nonNilStrings 把 nil 切片转换为空切片，JSON 中输出 [] 而不是 null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// newTrashedPostViews 转换回收站中的文章，retention 为回收站保留期
+func newTrashedPostViews(posts []Post, retention time.Duration) []TrashedPostView {
+	views := make([]TrashedPostView, 0, len(posts))
+	for _, post := range posts {
+		view := newPostView(post)
+		view.Comments = nil
+		views = append(views, TrashedPostView{
+			PostView:  view,
+			DeletedAt: post.DeletedAt.Time,
+			PurgeAt:   post.DeletedAt.Time.Add(retention),
+			TakenDown: post.TakenDown,
+		})
+	}
+	return views
+}
+
+// newCommentView 从评论模型生成响应
+func newCommentView(comment Comment) CommentView {
+	return CommentView{
+		ID:          comment.ID,
+		CreatedAt:   comment.CreatedAt,
+		UpdatedAt:   comment.UpdatedAt,
+		Content:     comment.Content,
+		ContentHTML: comment.ContentHTML,
+		Status:      comment.Status,
+		PostID:      comment.PostID,
+		ParentID:    comment.ParentID,
+		UserID:      comment.UserID,
+		Author:      newUserPublicView(comment.User),
+	}
+}
+
+// newCommentViews 批量转换评论列表
+func newCommentViews(comments []Comment) []CommentView {
+	views := make([]CommentView, 0, len(comments))
+	for _, comment := range comments {
+		views = append(views, newCommentView(comment))
+	}
+	return views
+}
+
+// newCommentNode 创建不含回复的树形节点
+func newCommentNode(comment Comment) *CommentNode {
+	return &CommentNode{CommentView: newCommentView(comment), Replies: []*CommentNode{}}
+}
+
+// newAuditLogViews 批量转换审计记录
+func newAuditLogViews(logs []AuditLog) []AuditLogView {
+	views := make([]AuditLogView, 0, len(logs))
+	for _, entry := range logs {
+		views = append(views, AuditLogView{
+			ID:        entry.ID,
+			CreatedAt: entry.CreatedAt,
+			Action:    entry.Action,
+			UserID:    entry.UserID,
+			Username:  entry.Username,
+			IP:        entry.IP,
+			Detail:    entry.Detail,
+		})
+	}
+	return views
+}